@@ -2,44 +2,418 @@ package rss
 
 import (
 	"context"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"html"
 	"io"
 	"net/http"
-
-	"github.com/paulrosania/go-charset/charset"
+	"net/url"
+	"strings"
 )
 
+// xmlNamespace is the namespace of the xml: prefix used by xml:base.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
 // Feed represents an Atom feed containing entries.
 // It follows the Atom 1.0 specification structure.
+//
+// The JSON representation uses lower camel case keys with plural names
+// for lists, like "entries" and "links", and omits empty fields.
 type Feed struct {
+	// ID is a permanent, universally unique identifier for the feed
+	ID string `xml:"id" json:"id,omitempty"`
+
+	// Title is the title of the feed
+	Title string `xml:"title" json:"title,omitempty"`
+
+	// Updated is the time when the feed was last modified
+	Updated string `xml:"updated" json:"updated,omitempty"`
+
+	// UpdatedTime is Updated resolved during parsing
+	UpdatedTime DateTime `xml:"-" json:"-"`
+
+	// Link is a list of links of the feed, like its website (rel="alternate")
+	// or its own URL (rel="self")
+	Link []Link `xml:"link,omitempty" json:"links,omitempty"`
+
 	// Entry is a slice of entries in the feed
-	Entry []Entry `xml:"entry"`
+	Entry []Entry `xml:"entry,omitempty" json:"entries,omitempty"`
+
+	// XMLBase is the base URL in scope of the feed element.
+	// After parsing it is the xml:base attribute of the feed resolved against
+	// the URL of the feed document without credentials, query and fragment,
+	// or empty if the feed declares no xml:base. A relative xml:base is kept
+	// as is if the URL of the document is unknown, see Feed.Normalize.
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr,omitempty" json:"xmlBase,omitempty"`
 }
 
 // Entry represents a single entry in an Atom feed.
 // Each entry typically represents a blog post, article, or other piece of content.
 type Entry struct {
 	// ID is a permanent, universally unique identifier for the entry
-	ID string `xml:"id"`
+	ID string `xml:"id" json:"id,omitempty"`
 
 	// Title is the title of the entry
-	Title string `xml:"title"`
+	Title string `xml:"title" json:"title,omitempty"`
 
 	// Updated is the time when the entry was last modified
-	Updated string `xml:"updated"`
+	Updated string `xml:"updated" json:"updated,omitempty"`
+
+	// Published is the time when the entry was first published
+	Published string `xml:"published,omitempty" json:"published,omitempty"`
+
+	// UpdatedTime is Updated resolved during parsing
+	UpdatedTime DateTime `xml:"-" json:"-"`
+
+	// PublishedTime is Published resolved during parsing
+	PublishedTime DateTime `xml:"-" json:"-"`
+
+	// Author is a list of authors of the entry
+	Author []Person `xml:"author,omitempty" json:"authors,omitempty"`
+
+	// Category is a list of categories of the entry
+	Category []Category `xml:"category,omitempty" json:"categories,omitempty"`
+
+	// Link is a list of links of the entry
+	Link []Link `xml:"link,omitempty" json:"links,omitempty"`
+
+	// Summary is a short summary or excerpt of the entry
+	Summary Content `xml:"summary" json:"summary,omitempty"`
+
+	// Content is the content of the entry
+	Content Content `xml:"content" json:"content,omitempty"`
+
+	// Source is the metadata of the feed the entry came from,
+	// set by aggregators like MergeAtom
+	Source *Source `xml:"source,omitempty" json:"source,omitempty"`
+
+	// XMLBase is the base URL in scope of the entry element.
+	// After parsing it is the resolved xml:base attribute of the entry or its feed,
+	// empty if neither declares one, or the relative xml:base of the entry
+	// if no absolute base is known.
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr,omitempty" json:"xmlBase,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// It omits the summary and content if they are empty.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type plainEntry Entry
+	var summary, content *Content
+	if !e.Summary.isEmpty() {
+		summary = &e.Summary
+	}
+	if !e.Content.isEmpty() {
+		content = &e.Content
+	}
+	return json.Marshal(struct {
+		plainEntry
+		Summary *Content `json:"summary,omitempty"`
+		Content *Content `json:"content,omitempty"`
+	}{plainEntry(e), summary, content})
+}
+
+// URL returns the resolved URL of the entry's alternate link,
+// or an empty string if the entry has no such link.
+func (e *Entry) URL() string {
+	return alternateLink(e.Link)
+}
+
+// URL returns the resolved URL of the feed's alternate link,
+// which usually points to the website of the feed.
+func (f *Feed) URL() string {
+	return alternateLink(f.Link)
+}
+
+// Person represents an author or contributor of an Atom entry.
+type Person struct {
+	// Name is the human-readable name of the person
+	Name string `xml:"name" json:"name,omitempty"`
+
+	// URI is the home page of the person
+	URI string `xml:"uri,omitempty" json:"uri,omitempty"`
+
+	// Email is the email address of the person
+	Email string `xml:"email,omitempty" json:"email,omitempty"`
+}
+
+// Source represents the source element of an Atom entry,
+// the metadata of the feed the entry was copied from.
+type Source struct {
+	// ID is the identifier of the source feed
+	ID string `xml:"id,omitempty" json:"id,omitempty"`
+
+	// Title is the title of the source feed
+	Title string `xml:"title,omitempty" json:"title,omitempty"`
+
+	// Updated is the time when the source feed was last modified
+	Updated string `xml:"updated,omitempty" json:"updated,omitempty"`
+
+	// Link is a list of links of the source feed like its own URL (rel="self")
+	Link []Link `xml:"link,omitempty" json:"links,omitempty"`
+}
+
+// Category represents a category element of an Atom entry.
+type Category struct {
+	// Term is the identifier of the category
+	Term string `xml:"term,attr" json:"term,omitempty"`
+
+	// Scheme is the URI of the categorization scheme
+	Scheme string `xml:"scheme,attr,omitempty" json:"scheme,omitempty"`
+
+	// Label is a human-readable label of the category
+	Label string `xml:"label,attr,omitempty" json:"label,omitempty"`
+}
+
+// Link represents a link element of an Atom feed or entry.
+type Link struct {
+	// Href is the URL of the link.
+	// After parsing it is resolved against the xml:base in scope.
+	Href string `xml:"href,attr" json:"href,omitempty"`
+
+	// Rel is the link relation type like "alternate", "self" or "enclosure".
+	// An empty Rel means "alternate".
+	Rel string `xml:"rel,attr,omitempty" json:"rel,omitempty"`
+
+	// Type is the MIME type of the linked resource
+	Type string `xml:"type,attr,omitempty" json:"type,omitempty"`
+
+	// Title is a human-readable description of the link
+	Title string `xml:"title,attr,omitempty" json:"title,omitempty"`
+
+	// Length is the size of the linked resource in bytes
+	Length string `xml:"length,attr,omitempty" json:"length,omitempty"`
+
+	// XMLBase is the xml:base attribute of the link element
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr,omitempty" json:"xmlBase,omitempty"`
+}
+
+// Content represents the content or summary of an Atom entry.
+type Content struct {
+	// Type is "text", "html", "xhtml" or a MIME type. Empty means "text".
+	Type string `json:"type,omitempty"`
+
+	// Src is the URL of out-of-line content.
+	// After parsing it is resolved against the xml:base in scope.
+	Src string `json:"src,omitempty"`
+
+	// Body is the content as found in the feed, unescaped for type "html".
+	// For type "xhtml" it is the markup inside the wrapping div element.
+	Body string `json:"body,omitempty"`
+
+	// XMLBase is the base URL in scope of the content element.
+	// After parsing it is the resolved xml:base attribute of the content or its entry,
+	// or the URL of the feed document without credentials, query and fragment.
+	// It is the relative xml:base of the content if no absolute base is known.
+	XMLBase string `json:"xmlBase,omitempty"`
+}
+
+// isEmpty checks if the content has neither a body nor a source.
+func (c *Content) isEmpty() bool {
+	return c.Body == "" && c.Src == ""
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+// It decodes the body depending on the content type.
+func (c *Content) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "type":
+			c.Type = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "src":
+			c.Src = attr.Value
+		case attr.Name.Space == xmlNamespace && attr.Name.Local == "base":
+			c.XMLBase = attr.Value
+		}
+	}
+
+	if c.Type == "xhtml" {
+		var inner struct {
+			XML string `xml:",innerxml"`
+		}
+		if err := d.DecodeElement(&inner, &start); err != nil {
+			return err
+		}
+		c.Body = unwrapXHTMLDiv(inner.XML)
+		return nil
+	}
+
+	var text struct {
+		Text string `xml:",chardata"`
+	}
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return err
+	}
+	c.Body = text.Text
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+// Empty content is omitted, XHTML content is wrapped in a div element.
+func (c Content) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.isEmpty() {
+		return nil
+	}
+	if c.Type != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "type"}, Value: c.Type})
+	}
+	if c.Src != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "src"}, Value: c.Src})
+	}
+	if c.Type == "xhtml" {
+		var div struct {
+			XMLNS string `xml:"xmlns,attr"`
+			XML   string `xml:",innerxml"`
+		}
+		div.XMLNS = "http://www.w3.org/1999/xhtml"
+		div.XML = c.Body
+		return e.EncodeElement(struct {
+			Div any `xml:"div"`
+		}{div}, start)
+	}
+	return e.EncodeElement(struct {
+		Text string `xml:",chardata"`
+	}{c.Body}, start)
+}
+
+// HTML returns the content as HTML with relative URLs resolved
+// against the xml:base in scope, including xml:base attributes
+// nested in XHTML content. Text content is HTML escaped.
+func (c *Content) HTML() string {
+	switch c.Type {
+	case "", "text":
+		return html.EscapeString(c.Body)
+	default:
+		base, err := url.Parse(c.XMLBase)
+		if err != nil {
+			return c.Body
+		}
+		return resolveHTMLURLs(base, c.Body)
+	}
+}
+
+// unwrapXHTMLDiv returns the inner markup of the div element
+// that wraps XHTML content according to the Atom spec.
+func unwrapXHTMLDiv(markup string) string {
+	markup = strings.TrimSpace(markup)
+	if !strings.HasPrefix(markup, "<div") || !strings.HasSuffix(markup, "</div>") {
+		return markup
+	}
+	start := strings.IndexByte(markup, '>')
+	if start < 0 || markup[start-1] == '/' {
+		return markup
+	}
+	return strings.TrimSpace(markup[start+1 : len(markup)-len("</div>")])
+}
+
+// alternateLink returns the href of the first alternate link.
+func alternateLink(links []Link) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// selfLink returns the href of the first link with rel="self",
+// which is the URL of the feed itself.
+func selfLink(links []Link) string {
+	for _, link := range links {
+		if link.Rel == "self" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// resolveBases resolves the xml:base scopes of the feed and all
+// its elements against the URL of the feed document, see Feed.XMLBase.
+//
+// Scopes and URLs are only resolved if the base in scope is absolute,
+// otherwise the raw values are kept, so that resolving again against
+// the actual URL of the document gives the correct result.
+// The feed and entries only get a base if the document declares one,
+// so the URL of the document, which may carry a token, is not copied.
+func (f *Feed) resolveBases(documentURL *url.URL) {
+	base := resolveBase(documentURL, f.XMLBase)
+	declared := strings.TrimSpace(f.XMLBase) != ""
+	f.XMLBase = scopeBase(base, f.XMLBase, declared)
+	resolveLinks(base, f.Link)
+
+	for i := range f.Entry {
+		entry := &f.Entry[i]
+		entryBase := resolveBase(base, entry.XMLBase)
+		entry.XMLBase = scopeBase(entryBase, entry.XMLBase, declared || strings.TrimSpace(entry.XMLBase) != "")
+		resolveLinks(entryBase, entry.Link)
+		entry.Summary.resolveBase(entryBase)
+		entry.Content.resolveBase(entryBase)
+		if entry.Source != nil {
+			resolveLinks(entryBase, entry.Source.Link)
+		}
+	}
+}
+
+func (c *Content) resolveBase(parent *url.URL) {
+	// The content always keeps its base for resolving URLs with HTML
+	base := resolveBase(parent, c.XMLBase)
+	c.XMLBase = scopeBase(base, c.XMLBase, true)
+	if base.IsAbs() {
+		c.Src = resolveURL(base, strings.TrimSpace(c.Src))
+	}
+}
+
+func resolveLinks(parent *url.URL, links []Link) {
+	for i := range links {
+		if base := resolveBase(parent, links[i].XMLBase); base.IsAbs() {
+			links[i].Href = resolveURL(base, strings.TrimSpace(links[i].Href))
+		}
+	}
+}
+
+// scopeBase returns the base in scope of an element with the raw xml:base
+// attribute: the resolved base without credentials, query and fragment
+// if it is absolute, or the raw attribute. Returns an empty string
+// if no xml:base was declared for the element or its ancestors.
+func scopeBase(base *url.URL, xmlBase string, declared bool) string {
+	if !declared {
+		return ""
+	}
+	if base.IsAbs() {
+		stripped := *base
+		stripped.User = nil
+		stripped.RawQuery = ""
+		stripped.ForceQuery = false
+		stripped.Fragment = ""
+		stripped.RawFragment = ""
+		return stripped.String()
+	}
+	return xmlBase
 }
 
 // ParseAtom parses an Atom 1.0 feed from an io.Reader.
 // It expects the reader to contain valid Atom XML.
 // The context is used for cancellation control during parsing.
 //
-// The function automatically handles character encoding detection and conversion
-// using the go-charset library, supporting various encodings commonly found
-// in Atom feeds.
+// The character encoding is detected from the byte order mark or the XML declaration
+// and converted to UTF-8, supporting various encodings commonly found in Atom feeds.
+//
+// Relative URLs are resolved against xml:base attributes in the feed.
+//
+// The root element must be a feed element in the Atom namespace or without
+// namespace, otherwise an error wrapping ErrWrongFormat is returned. Feeds without
+// entries are valid, use ParseAtomWithOptions with ParseOptions.RequireItems to reject them.
 //
 // Returns a Feed struct containing the parsed Atom data and any error that occurred.
 // The reader is not closed by this function; the caller is responsible for closing it.
 func ParseAtom(ctx context.Context, r io.Reader) (*Feed, error) {
+	return parseAtom(ctx, r, "", nil, ParseOptions{})
+}
+
+// parseAtom parses an Atom feed using the charset of the contentType,
+// which may be empty, to decode the document and resolves its
+// xml:base scopes against the documentURL which may be nil if unknown.
+func parseAtom(ctx context.Context, r io.Reader, contentType string, documentURL *url.URL, options ParseOptions) (*Feed, error) {
 	// Check if context is cancelled before starting
 	select {
 	case <-ctx.Done():
@@ -47,12 +421,36 @@ func ParseAtom(ctx context.Context, r io.Reader) (*Feed, error) {
 	default:
 	}
 
-	xmlDecoder := xml.NewDecoder(r)
-	xmlDecoder.CharsetReader = charset.NewReader
+	feed, err := decodeAtom(newDecoder(r, contentType), documentURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(feed.Entry) == 0 && options.RequireItems {
+		return nil, fmt.Errorf("%w: feed %q", ErrEmptyFeed, feed.Title)
+	}
+	feed.resolveDates(options)
+	return feed, nil
+}
+
+// decodeAtom decodes an Atom document after checking its root element
+// and resolves its xml:base scopes against the documentURL which may be nil if unknown.
+func decodeAtom(xmlDecoder *xml.Decoder, documentURL *url.URL) (*Feed, error) {
+	start, err := rootStart(xmlDecoder)
+	if err != nil {
+		return nil, err
+	}
+	if !isAtomRoot(start.Name) {
+		return nil, &FormatError{Expected: FormatAtom, Root: start.Name}
+	}
+
 	feed := Feed{}
-	if err := xmlDecoder.Decode(&feed); err != nil {
+	if err := xmlDecoder.DecodeElement(&feed, &start); err != nil {
 		return nil, err
 	}
+	if documentURL == nil {
+		documentURL = &url.URL{}
+	}
+	feed.resolveBases(documentURL)
 	return &feed, nil
 }
 
@@ -60,13 +458,21 @@ func ParseAtom(ctx context.Context, r io.Reader) (*Feed, error) {
 // It expects the response body to contain valid Atom XML.
 // The context is used for cancellation control during parsing.
 //
-// The function automatically handles character encoding detection and conversion
-// using the go-charset library, supporting various encodings commonly found
-// in Atom feeds.
+// The character encoding is detected from the byte order mark, the charset of the
+// Content-Type header or the XML declaration, in this order of precedence (RFC 7303),
+// and converted to UTF-8.
+//
+// Relative URLs are resolved against xml:base attributes and the URL of the request.
+//
+// Like ParseAtom it returns errors wrapping ErrWrongFormat.
 //
 // Returns a Feed struct containing the parsed Atom data and any error that occurred.
 // The response body is automatically closed after parsing.
 func Atom(ctx context.Context, resp *http.Response) (*Feed, error) {
 	defer resp.Body.Close()
-	return ParseAtom(ctx, resp.Body)
+	var documentURL *url.URL
+	if resp.Request != nil {
+		documentURL = resp.Request.URL
+	}
+	return parseAtom(ctx, resp.Body, resp.Header.Get("Content-Type"), documentURL, ParseOptions{})
 }
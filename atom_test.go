@@ -0,0 +1,200 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const xmlBaseAtomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:base="/blog/">
+	<title>Base Test</title>
+	<link href="./"/>
+	<link rel="self" href="feed.atom"/>
+	<entry xml:base="2024/">
+		<id>tag:example.com,2024:1</id>
+		<title>First</title>
+		<updated>2024-01-01T12:00:00Z</updated>
+		<link href="first.html"/>
+		<link rel="enclosure" href="https://cdn.example.org/first.mp3" length="1024" type="audio/mpeg"/>
+		<summary type="html">&lt;a href="first.html#more"&gt;more&lt;/a&gt;</summary>
+		<content type="xhtml" xml:base="content/">
+			<div xmlns="http://www.w3.org/1999/xhtml">
+				<p><img src="a.png"/></p>
+				<p xml:base="/other/"><a href="b.html">b</a></p>
+			</div>
+		</content>
+	</entry>
+</feed>`
+
+// TestAtomXMLBase tests resolution of nested xml:base scopes
+func TestAtomXMLBase(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(xmlBaseAtomFeed)),
+				Request:    req,
+			}, nil
+		}),
+	}
+	resp, err := ReadWithClient(context.Background(), "https://example.com/feeds/main", client, false)
+	if err != nil {
+		t.Fatalf("ReadWithClient failed: %v", err)
+	}
+
+	feed, err := Atom(context.Background(), resp)
+	if err != nil {
+		t.Fatalf("Atom failed: %v", err)
+	}
+
+	if feed.URL() != "https://example.com/blog/" {
+		t.Errorf("Expected feed URL 'https://example.com/blog/', got '%s'", feed.URL())
+	}
+	if feed.Link[1].Href != "https://example.com/blog/feed.atom" {
+		t.Errorf("Expected resolved self link, got '%s'", feed.Link[1].Href)
+	}
+
+	entry := feed.Entry[0]
+	if entry.URL() != "https://example.com/blog/2024/first.html" {
+		t.Errorf("Expected resolved entry URL, got '%s'", entry.URL())
+	}
+	if entry.Link[1].Href != "https://cdn.example.org/first.mp3" || entry.Link[1].Length != "1024" {
+		t.Errorf("Unexpected enclosure link: %+v", entry.Link[1])
+	}
+	if entry.Summary.HTML() != `<a href="https://example.com/blog/2024/first.html#more">more</a>` {
+		t.Errorf("Unexpected summary HTML: %s", entry.Summary.HTML())
+	}
+
+	contentHTML := entry.Content.HTML()
+	for _, expected := range []string{
+		`src="https://example.com/blog/2024/content/a.png"`,
+		`href="https://example.com/other/b.html"`,
+	} {
+		if !strings.Contains(contentHTML, expected) {
+			t.Errorf("Expected content to contain %s, got %s", expected, contentHTML)
+		}
+	}
+	if strings.HasPrefix(entry.Content.Body, "<div") {
+		t.Errorf("Expected XHTML wrapper div to be removed, got %s", entry.Content.Body)
+	}
+}
+
+// TestParseAtomRelativeXMLBase tests that ParseAtom keeps relative bases and
+// Normalize resolves them against the feed URL
+func TestParseAtomRelativeXMLBase(t *testing.T) {
+	feed, err := ParseAtom(context.Background(), strings.NewReader(xmlBaseAtomFeed))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	if feed.Entry[0].URL() != "first.html" || feed.Entry[0].XMLBase != "2024/" || feed.XMLBase != "/blog/" {
+		t.Errorf("Expected raw URL and bases, got '%s', '%s' and '%s'", feed.Entry[0].URL(), feed.Entry[0].XMLBase, feed.XMLBase)
+	}
+
+	if err := feed.Normalize("https://example.com/feeds/main"); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if feed.Entry[0].URL() != "https://example.com/blog/2024/first.html" {
+		t.Errorf("Expected absolute entry URL, got '%s'", feed.Entry[0].URL())
+	}
+	if _, err := url.Parse(feed.Entry[0].Content.XMLBase); err != nil || !strings.HasPrefix(feed.Entry[0].Content.XMLBase, "https://") {
+		t.Errorf("Expected absolute content base, got '%s'", feed.Entry[0].Content.XMLBase)
+	}
+}
+
+// TestParseAtomNonRootedXMLBase tests that relative bases without leading slash
+// are resolved against the feed URL by Normalize
+func TestParseAtomNonRootedXMLBase(t *testing.T) {
+	feed, err := ParseAtom(context.Background(), strings.NewReader(`<feed xmlns="http://www.w3.org/2005/Atom" xml:base="blog/">
+		<title>Base Test</title>
+		<entry xml:base="2024/">
+			<id>1</id>
+			<title>First</title>
+			<link href="post.html"/>
+			<content type="html" src="full.html"/>
+		</entry>
+	</feed>`))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	if err := feed.Normalize("https://example.com/feeds/atom.xml"); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	entry := &feed.Entry[0]
+	if entry.URL() != "https://example.com/feeds/blog/2024/post.html" {
+		t.Errorf("Expected URL relative to the feed, got '%s'", entry.URL())
+	}
+	if entry.Content.Src != "https://example.com/feeds/blog/2024/full.html" {
+		t.Errorf("Expected content source relative to the feed, got '%s'", entry.Content.Src)
+	}
+	if feed.XMLBase != "https://example.com/feeds/blog/" || entry.XMLBase != "https://example.com/feeds/blog/2024/" {
+		t.Errorf("Expected resolved bases, got '%s' and '%s'", feed.XMLBase, entry.XMLBase)
+	}
+
+	// Normalizing again doesn't change the resolved URLs
+	if err := feed.Normalize("https://example.com/feeds/atom.xml"); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if entry.URL() != "https://example.com/feeds/blog/2024/post.html" {
+		t.Errorf("Expected stable URL, got '%s'", entry.URL())
+	}
+}
+
+// TestAtomXMLBaseWithoutToken tests that the URL of the document is not
+// copied into the parsed feed, because it may carry a token
+func TestAtomXMLBaseWithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+			<title>Private</title>
+			<entry>
+				<id>1</id>
+				<title>First</title>
+				<link href="/posts/1"/>
+				<content type="html">&lt;img src="/images/1.png"&gt;</content>
+			</entry>
+		</feed>`))
+	}))
+	defer server.Close()
+
+	provider := StaticCredentials{strings.TrimPrefix(server.URL, "http://"): {Token: "SECRET", QueryParam: "private_token"}}
+	resp, err := ReadWithCredentials(context.Background(), server.URL+"/feed.atom", server.Client(), provider, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := Atom(context.Background(), resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &feed.Entry[0]
+	if entry.URL() != server.URL+"/posts/1" {
+		t.Errorf("Expected resolved entry URL, got '%s'", entry.URL())
+	}
+	if html := entry.Content.HTML(); html != `<img src="`+server.URL+`/images/1.png">` {
+		t.Errorf("Expected resolved content, got %s", html)
+	}
+	if feed.XMLBase != "" || entry.XMLBase != "" {
+		t.Errorf("Expected no undeclared bases, got '%s' and '%s'", feed.XMLBase, entry.XMLBase)
+	}
+
+	data, err := json.Marshal(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, feed); err != nil {
+		t.Fatal(err)
+	}
+	for _, output := range []string{string(data), buf.String()} {
+		if strings.Contains(output, "SECRET") || strings.Contains(output, "private_token") {
+			t.Errorf("Token leaked into output:\n%s", output)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secrets in URLs and error messages.
+const redacted = "***"
+
+// sensitiveQueryParams are query parameter names whose values are
+// redacted from error messages even if no CredentialsProvider is used.
+var sensitiveQueryParams = []string{
+	"token",
+	"access_token",
+	"private_token",
+	"api_key",
+	"apikey",
+	"key",
+	"secret",
+	"password",
+	"auth",
+}
+
+// userinfoPattern matches the password part of URL-embedded credentials
+// in strings that could not be parsed as URL.
+var userinfoPattern = regexp.MustCompile(`(//[^/:@\s]*):[^/@\s]*@`)
+
+// Credentials holds the secrets used to authenticate a feed request.
+// Exactly one authentication method is applied, checked in this order:
+// query token, bearer token, HTTP Basic auth.
+type Credentials struct {
+	// Username and Password are sent using HTTP Basic authentication
+	Username string
+	Password string
+
+	// Token is sent as bearer token in the Authorization header,
+	// or as query parameter if QueryParam is set
+	Token string
+
+	// QueryParam is the name of the query parameter carrying Token,
+	// for example "private_token" for GitLab feeds
+	QueryParam string
+}
+
+// apply adds the credentials to the request.
+func (c *Credentials) apply(req *http.Request) {
+	switch {
+	case c.Token != "" && c.QueryParam != "":
+		query := req.URL.Query()
+		query.Set(c.QueryParam, c.Token)
+		req.URL.RawQuery = query.Encode()
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "" || c.Password != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// secrets returns the non-empty secret values of the credentials.
+func (c *Credentials) secrets() []string {
+	var secrets []string
+	for _, s := range []string{c.Password, c.Token} {
+		if s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+// CredentialsProvider defines the interface for looking up the credentials
+// of a feed host. This allows credentials to be loaded from configuration
+// files, secret stores or environment variables on demand.
+type CredentialsProvider interface {
+	// Credentials returns the credentials for the given host, which
+	// includes the port if the feed URL has one.
+	// It returns nil credentials and a nil error if the host needs no authentication.
+	Credentials(ctx context.Context, host string) (*Credentials, error)
+}
+
+// StaticCredentials is a CredentialsProvider backed by a map from host to credentials.
+// Keys may be a host with port ("example.com:8443") or a plain hostname ("example.com"),
+// the more specific key takes precedence.
+type StaticCredentials map[string]Credentials
+
+// Credentials implements the CredentialsProvider interface.
+func (s StaticCredentials) Credentials(ctx context.Context, host string) (*Credentials, error) {
+	if c, ok := s[host]; ok {
+		return &c, nil
+	}
+	if hostname, _, found := strings.Cut(host, ":"); found {
+		if c, ok := s[hostname]; ok {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// ReadWithCredentials fetches an RSS or Atom feed from the given URL using a custom
+// HTTP client and credentials looked up from the provider by the host of the URL.
+// Credentials from the provider take precedence over credentials embedded in the URL.
+// A nil provider provides no credentials.
+//
+// Secrets are redacted from the returned errors, so they can be logged safely.
+//
+// Returns an HTTP response that should be closed by the caller.
+// The response body should be passed to either Regular() or Atom() for parsing.
+func ReadWithCredentials(ctx context.Context, url string, client *http.Client, provider CredentialsProvider, reddit bool) (*http.Response, error) {
+	req, err := newRequest(ctx, url, reddit)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return do(client, req)
+	}
+
+	creds, err := provider.Credentials(ctx, req.URL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials for %s: %w", req.URL.Host, err)
+	}
+	if creds == nil {
+		return do(client, req)
+	}
+
+	if creds.Token != "" || creds.Username != "" || creds.Password != "" {
+		// Don't mix credentials from the URL with the ones from the provider
+		req.Header.Del("Authorization")
+	}
+	creds.apply(req)
+	return do(client, req, creds.secrets()...)
+}
+
+// redactError returns err with credentials and the passed secrets
+// removed from its message. The returned error still wraps the cause
+// of err so errors.Is and errors.As keep working.
+func redactError(err error, secrets ...string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL, secrets...)
+	}
+
+	msg := err.Error()
+	redactedMsg := redactString(msg, secrets...)
+	if redactedMsg == msg {
+		return err
+	}
+	return &redactedError{msg: redactedMsg, err: err}
+}
+
+// redactURL masks the password of URL-embedded credentials,
+// the values of sensitive query parameters and the passed secrets.
+func redactURL(rawURL string, secrets ...string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redactString(rawURL, secrets...)
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if isSensitiveQueryParam(name) {
+				query.Set(name, redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	// Encoding escapes the asterisks, undo that for readability
+	s := strings.ReplaceAll(u.String(), url.QueryEscape(redacted), redacted)
+	return redactString(s, secrets...)
+}
+
+// redactString masks URL-embedded passwords and the passed secrets in s.
+func redactString(s string, secrets ...string) string {
+	s = userinfoPattern.ReplaceAllString(s, "${1}:"+redacted+"@")
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, redacted)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			s = strings.ReplaceAll(s, escaped, redacted)
+		}
+	}
+	return s
+}
+
+func isSensitiveQueryParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range sensitiveQueryParams {
+		if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedError is an error with secrets removed from its message.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,441 @@
+package rss
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached feed response.
+type CacheEntry struct {
+	// URL is the URL the response was fetched from
+	URL string `json:"url"`
+
+	// Body is the complete response body
+	Body []byte `json:"body"`
+
+	// ContentType is the Content-Type header of the response
+	ContentType string `json:"contentType,omitempty"`
+
+	// ETag is the entity tag used for If-None-Match revalidation
+	ETag string `json:"etag,omitempty"`
+
+	// LastModified is the Last-Modified header used for If-Modified-Since revalidation
+	LastModified string `json:"lastModified,omitempty"`
+
+	// Expires is the time until the entry is fresh, computed from
+	// Cache-Control max-age or the Expires header. Zero means the
+	// entry must be revalidated before use.
+	Expires time.Time `json:"expires,omitempty"`
+
+	// FetchedAt is the time the entry was fetched or last revalidated
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// Fresh reports if the entry can be served without revalidation at the given time.
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+// CacheStore defines the interface for storing cached feed responses.
+// Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the entry for the given key or nil if there is none.
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+
+	// Set stores the entry under the given key.
+	Set(ctx context.Context, key string, entry *CacheEntry) error
+
+	// Delete removes the entry with the given key if it exists.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryCache is an in-memory CacheStore that evicts the least recently
+// used entries when its capacity is reached.
+type MemoryCache struct {
+	mtx      sync.Mutex
+	capacity int
+	order    *list.List // of *memoryCacheElem, most recently used at front
+	elems    map[string]*list.Element
+}
+
+type memoryCacheElem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache returns a MemoryCache holding at most capacity entries.
+// A capacity <= 0 means the cache is unbounded.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		elems:    make(map[string]*list.Element),
+	}
+}
+
+// Get implements the CacheStore interface.
+func (c *MemoryCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elem, ok := c.elems[key]
+	if !ok {
+		return nil, nil
+	}
+	c.order.MoveToFront(elem)
+	entry := *elem.Value.(*memoryCacheElem).entry
+	return &entry, nil
+}
+
+// Set implements the CacheStore interface.
+func (c *MemoryCache) Set(ctx context.Context, key string, entry *CacheEntry) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stored := *entry
+	if elem, ok := c.elems[key]; ok {
+		elem.Value.(*memoryCacheElem).entry = &stored
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	c.elems[key] = c.order.PushFront(&memoryCacheElem{key: key, entry: &stored})
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elems, oldest.Value.(*memoryCacheElem).key)
+	}
+	return nil
+}
+
+// Delete implements the CacheStore interface.
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, ok := c.elems[key]; ok {
+		c.order.Remove(elem)
+		delete(c.elems, key)
+	}
+	return nil
+}
+
+// Len returns the number of cached entries.
+func (c *MemoryCache) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.order.Len()
+}
+
+// FileCache is a CacheStore that persists every entry as JSON file
+// in a directory, so cached feeds survive restarts.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache returns a FileCache storing its entries in dir.
+// The directory is created if it does not exist.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// path returns the file path for a key.
+// Keys are hashed because URLs are no valid file names.
+func (c *FileCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// Get implements the CacheStore interface.
+func (c *FileCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Set implements the CacheStore interface.
+// The entry is written to a temporary file first and then renamed,
+// so readers never see partially written entries.
+func (c *FileCache) Set(ctx context.Context, key string, entry *CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Delete implements the CacheStore interface.
+func (c *FileCache) Delete(ctx context.Context, key string) error {
+	err := os.Remove(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// CachingTransport is an http.RoundTripper that caches GET responses in a CacheStore.
+// Fresh entries are served without network access, stale entries are
+// revalidated with conditional requests using ETag and Last-Modified.
+//
+// It can be used with all read functions by wrapping it in an http.Client:
+//
+//	client := &http.Client{Transport: &rss.CachingTransport{Store: rss.NewMemoryCache(100)}}
+//	resp, err := rss.ReadWithClient(ctx, url, client, false)
+//
+// Responses served from the cache have the header X-Cache set to "HIT",
+// revalidated ones "REVALIDATED" and fetched ones "MISS".
+//
+// Requests with Authorization or Cookie headers are cached separately per
+// credentials, so a response is never served to a request with other or no
+// credentials. Responses marked as private or no-store and responses that
+// vary by request headers other than Accept-Encoding are not stored.
+type CachingTransport struct {
+	// Store holds the cached entries
+	Store CacheStore
+
+	// Transport is the underlying transport, http.DefaultTransport if nil
+	Transport http.RoundTripper
+
+	// Now returns the current time, time.Now if nil
+	Now func() time.Time
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	key := cacheKey(req)
+	now := t.now()
+
+	entry, err := t.Store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+	if entry != nil && entry.Fresh(now) {
+		return entry.response(req, "HIT"), nil
+	}
+
+	if entry != nil && (entry.ETag != "" || entry.LastModified != "") {
+		req = req.Clone(ctx)
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		entry.FetchedAt = now
+		entry.Expires = expires(resp.Header, now)
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			entry.ETag = etag
+		}
+		if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+			entry.LastModified = lastModified
+		}
+		if err := t.Store.Set(ctx, key, entry); err != nil {
+			return nil, fmt.Errorf("failed to write cache: %w", err)
+		}
+		return entry.response(req, "REVALIDATED"), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || !storable(resp.Header) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	entry = &CacheEntry{
+		URL:          redactURL(req.URL.String()),
+		Body:         body,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Expires:      expires(resp.Header, now),
+		FetchedAt:    now,
+	}
+	if err := t.Store.Set(ctx, key, entry); err != nil {
+		return nil, fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.Header.Set("X-Cache", "MISS")
+	return resp, nil
+}
+
+// cacheKey returns the URL of the request, followed by a hash of the
+// Authorization and Cookie headers if the request has credentials.
+func cacheKey(req *http.Request) string {
+	key := req.URL.String()
+	authorization, cookies := req.Header.Values("Authorization"), req.Header.Values("Cookie")
+	if len(authorization) == 0 && len(cookies) == 0 {
+		return key
+	}
+	hash := sha256.New()
+	for _, values := range [][]string{authorization, cookies} {
+		for _, value := range values {
+			hash.Write([]byte(value))
+			hash.Write([]byte{0})
+		}
+		hash.Write([]byte{1})
+	}
+	return key + " credentials:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// storable reports if a response with the header may be stored.
+// Responses varying by headers other than Accept-Encoding are not stored
+// because the cache key doesn't include the request headers.
+func storable(header http.Header) bool {
+	for _, directive := range cacheDirectives(header) {
+		if directive == "no-store" || directive == "private" || strings.HasPrefix(directive, "private=") {
+			return false
+		}
+	}
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" && !strings.EqualFold(name, "Accept-Encoding") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (t *CachingTransport) transport() http.RoundTripper {
+	if t.Transport == nil {
+		return http.DefaultTransport
+	}
+	return t.Transport
+}
+
+func (t *CachingTransport) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
+
+// response creates a synthetic HTTP response for a cached entry.
+func (e *CacheEntry) response(req *http.Request, cacheStatus string) *http.Response {
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("Last-Modified", e.LastModified)
+	}
+	header.Set("X-Cache", cacheStatus)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// expires computes until when a response is fresh.
+// Cache-Control max-age takes precedence over the Expires header,
+// no-cache makes the response stale immediately.
+func expires(header http.Header, now time.Time) time.Time {
+	if hasCacheDirective(header, "no-cache") || hasCacheDirective(header, "no-store") {
+		return time.Time{}
+	}
+	for _, directive := range cacheDirectives(header) {
+		if value, found := strings.CutPrefix(directive, "max-age="); found {
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || seconds <= 0 {
+				return time.Time{}
+			}
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	if value := header.Get("Expires"); value != "" {
+		t, err := http.ParseTime(value)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func cacheDirectives(header http.Header) []string {
+	var directives []string
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directives = append(directives, strings.ToLower(strings.TrimSpace(directive)))
+		}
+	}
+	return directives
+}
+
+func hasCacheDirective(header http.Header, name string) bool {
+	for _, directive := range cacheDirectives(header) {
+		if directive == name {
+			return true
+		}
+	}
+	return false
+}
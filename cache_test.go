@@ -0,0 +1,208 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cacheTestFeed = `<rss version="2.0"><channel><title>Cached</title><item><title>Item</title></item></channel></rss>`
+
+// TestCachingTransport tests fresh hits and conditional revalidation
+func TestCachingTransport(t *testing.T) {
+	requests := 0
+	conditional := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(cacheTestFeed))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryCache(10)
+	client := &http.Client{Transport: &CachingTransport{
+		Store: store,
+		Now:   func() time.Time { return now },
+	}}
+	ctx := context.Background()
+
+	fetch := func(expectedCache string) {
+		t.Helper()
+		resp, err := ReadWithClient(ctx, server.URL, client, false)
+		if err != nil {
+			t.Fatalf("ReadWithClient failed: %v", err)
+		}
+		if got := resp.Header.Get("X-Cache"); got != expectedCache {
+			t.Errorf("Expected X-Cache %q, got %q", expectedCache, got)
+		}
+		channel, err := Regular(ctx, resp)
+		if err != nil {
+			t.Fatalf("Regular failed: %v", err)
+		}
+		if channel.Title != "Cached" {
+			t.Errorf("Expected title 'Cached', got '%s'", channel.Title)
+		}
+	}
+
+	fetch("MISS")
+	fetch("HIT")
+	if requests != 1 {
+		t.Errorf("Expected 1 request for fresh entry, got %d", requests)
+	}
+
+	now = now.Add(2 * time.Minute)
+	fetch("REVALIDATED")
+	if requests != 2 || conditional != 1 {
+		t.Errorf("Expected 1 conditional request, got %d requests and %d conditional", requests, conditional)
+	}
+}
+
+// TestCachingTransportCredentials tests that responses are cached per credentials
+func TestCachingTransportCredentials(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		user, _, _ := r.BasicAuth()
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, `<rss version="2.0"><channel><title>Feed of %s</title><item><title>Item</title></item></channel></rss>`, user)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &CachingTransport{Store: NewMemoryCache(10)}}
+	ctx := context.Background()
+
+	fetch := func(provider CredentialsProvider, expectedTitle, expectedCache string) {
+		t.Helper()
+		resp, err := ReadWithCredentials(ctx, server.URL, client, provider, false)
+		if err != nil {
+			t.Fatalf("ReadWithCredentials failed: %v", err)
+		}
+		if got := resp.Header.Get("X-Cache"); got != expectedCache {
+			t.Errorf("Expected X-Cache %q, got %q", expectedCache, got)
+		}
+		channel, err := Regular(ctx, resp)
+		if err != nil {
+			t.Fatalf("Regular failed: %v", err)
+		}
+		if channel.Title != expectedTitle {
+			t.Errorf("Expected title %q, got %q", expectedTitle, channel.Title)
+		}
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	alice := StaticCredentials{host: {Username: "alice", Password: "secret1"}}
+	bob := StaticCredentials{host: {Username: "bob", Password: "secret2"}}
+
+	fetch(alice, "Feed of alice", "MISS")
+	fetch(bob, "Feed of bob", "MISS")
+	fetch(nil, "Feed of ", "MISS")
+	fetch(alice, "Feed of alice", "HIT")
+	fetch(bob, "Feed of bob", "HIT")
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+}
+
+// TestCachingTransportNotStorable tests that private and varying responses are not stored
+func TestCachingTransportNotStorable(t *testing.T) {
+	tests := []struct {
+		header, value string
+		stored        bool
+	}{
+		{"Cache-Control", "private, max-age=60", false},
+		{"Cache-Control", `private="Set-Cookie", max-age=60`, false},
+		{"Vary", "Accept-Language", false},
+		{"Vary", "*", false},
+		{"Vary", "Accept-Encoding", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header+": "+tt.value, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set(tt.header, tt.value)
+				w.Write([]byte(cacheTestFeed))
+			}))
+			defer server.Close()
+
+			store := NewMemoryCache(10)
+			client := &http.Client{Transport: &CachingTransport{Store: store}}
+			resp, err := ReadWithClient(context.Background(), server.URL, client, false)
+			if err != nil {
+				t.Fatalf("ReadWithClient failed: %v", err)
+			}
+			resp.Body.Close()
+			if stored := store.Len() == 1; stored != tt.stored {
+				t.Errorf("Expected stored %v, got %v", tt.stored, stored)
+			}
+		})
+	}
+}
+
+// TestMemoryCacheEviction tests that the least recently used entry is evicted
+func TestMemoryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+	for i := 0; i < 2; i++ {
+		cache.Set(ctx, fmt.Sprint(i), &CacheEntry{URL: fmt.Sprint(i)})
+	}
+	cache.Get(ctx, "0") // 1 is now least recently used
+	cache.Set(ctx, "2", &CacheEntry{URL: "2"})
+
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+	if entry, _ := cache.Get(ctx, "1"); entry != nil {
+		t.Error("Expected entry 1 to be evicted")
+	}
+	if entry, _ := cache.Get(ctx, "0"); entry == nil {
+		t.Error("Expected entry 0 to be cached")
+	}
+}
+
+// TestFileCache tests that entries persist across FileCache instances
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	cache, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("NewFileCache failed: %v", err)
+	}
+	fetchedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	err = cache.Set(ctx, "https://example.com/feed", &CacheEntry{
+		URL:       "https://example.com/feed",
+		Body:      []byte(cacheTestFeed),
+		ETag:      `"v1"`,
+		FetchedAt: fetchedAt,
+	})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	reopened, _ := NewFileCache(dir)
+	entry, err := reopened.Get(ctx, "https://example.com/feed")
+	if err != nil || entry == nil {
+		t.Fatalf("Get returned %v, %v", entry, err)
+	}
+	if string(entry.Body) != cacheTestFeed || entry.ETag != `"v1"` || !entry.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	if err := reopened.Delete(ctx, "https://example.com/feed"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if entry, _ := reopened.Get(ctx, "https://example.com/feed"); entry != nil {
+		t.Error("Expected deleted entry to be gone")
+	}
+}
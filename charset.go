@@ -0,0 +1,94 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// utf8BOM is the byte order mark of UTF-8 encoded documents.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// newDecoder returns an XML decoder reading r converted to UTF-8.
+// The encoding is determined according to RFC 7303, in order of precedence:
+//
+//  1. The byte order mark (UTF-8, UTF-16BE, UTF-16LE)
+//  2. The charset parameter of the contentType, which may be empty
+//  3. The encoding of the XML declaration
+//  4. UTF-8
+func newDecoder(r io.Reader, contentType string) *xml.Decoder {
+	r, isUTF8 := utf8Reader(r, contentType)
+	return newUTF8Decoder(r, isUTF8)
+}
+
+// newUTF8Decoder returns an XML decoder for r. If isUTF8 is true, r was
+// already converted to UTF-8 and the encoding of the XML declaration is ignored.
+func newUTF8Decoder(r io.Reader, isUTF8 bool) *xml.Decoder {
+	xmlDecoder := xml.NewDecoder(r)
+	if isUTF8 {
+		xmlDecoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	} else {
+		xmlDecoder.CharsetReader = charsetReader
+	}
+	return xmlDecoder
+}
+
+// utf8Reader converts r to UTF-8 if the encoding can be determined from
+// the byte order mark or the contentType. The returned bool is false
+// if the encoding has to be taken from the XML declaration.
+func utf8Reader(r io.Reader, contentType string) (io.Reader, bool) {
+	buffered := bufio.NewReader(r)
+	start, _ := buffered.Peek(3)
+
+	switch {
+	case bytes.HasPrefix(start, utf8BOM):
+		buffered.Discard(len(utf8BOM))
+		return buffered, true
+	case bytes.HasPrefix(start, []byte{0xFE, 0xFF}):
+		return transform.NewReader(buffered, unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()), true
+	case bytes.HasPrefix(start, []byte{0xFF, 0xFE}):
+		return transform.NewReader(buffered, unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()), true
+	}
+
+	if contentType == "" {
+		return buffered, false
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] == "" {
+		return buffered, false
+	}
+	enc, err := htmlindex.Get(params["charset"])
+	if err != nil {
+		// Fall back to the XML declaration for unknown charsets
+		return buffered, false
+	}
+	return decodeReader(buffered, enc), true
+}
+
+// charsetReader converts input from the encoding with the given label
+// to UTF-8. It supports the encodings of the WHATWG encoding standard
+// like windows-1252, ISO-8859-x, KOI8-R, Shift_JIS, GB18030 and EUC-KR.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	return decodeReader(input, enc), nil
+}
+
+func decodeReader(r io.Reader, enc encoding.Encoding) io.Reader {
+	if enc == unicode.UTF8 || enc == encoding.Nop {
+		return r
+	}
+	return transform.NewReader(r, enc.NewDecoder())
+}
@@ -0,0 +1,96 @@
+package rss
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encodedFeed returns an RSS document with the given title and
+// XML declaration encoding, encoded with enc.
+func encodedFeed(t *testing.T, enc encoding.Encoding, declaredEncoding, title string) string {
+	t.Helper()
+	doc := `<?xml version="1.0" encoding="` + declaredEncoding + `"?>
+<rss version="2.0"><channel><title>` + title + `</title><item><title>Item</title></item></channel></rss>`
+	encoded, err := enc.NewEncoder().String(doc)
+	if err != nil {
+		t.Fatalf("Failed to encode test feed: %v", err)
+	}
+	return encoded
+}
+
+// TestParseRegularCharsets tests decoding of encodings declared in the XML declaration
+func TestParseRegularCharsets(t *testing.T) {
+	testCases := []struct {
+		name  string
+		enc   encoding.Encoding
+		label string
+		title string
+	}{
+		{"windows-1252", charmap.Windows1252, "windows-1252", "Café – “quoted”"},
+		{"ISO-8859-1", charmap.ISO8859_1, "ISO-8859-1", "Größe"},
+		{"KOI8-R", charmap.KOI8R, "KOI8-R", "Привет"},
+		{"Shift_JIS", japanese.ShiftJIS, "Shift_JIS", "こんにちは"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := encodedFeed(t, tc.enc, tc.label, tc.title)
+			channel, err := ParseRegular(context.Background(), strings.NewReader(doc))
+			if err != nil {
+				t.Fatalf("ParseRegular failed: %v", err)
+			}
+			if channel.Title != tc.title {
+				t.Errorf("Expected title %q, got %q", tc.title, channel.Title)
+			}
+		})
+	}
+}
+
+// TestCharsetPrecedence tests the RFC 7303 precedence of BOM,
+// Content-Type charset and XML declaration
+func TestCharsetPrecedence(t *testing.T) {
+	response := func(contentType, body string) *http.Response {
+		return &http.Response{
+			Header: http.Header{"Content-Type": {contentType}},
+			Body:   io.NopCloser(strings.NewReader(body)),
+		}
+	}
+	ctx := context.Background()
+
+	// The Content-Type charset overrides a wrong XML declaration
+	doc := encodedFeed(t, charmap.Windows1251, "UTF-8", "Привет")
+	channel, err := Regular(ctx, response("application/rss+xml; charset=windows-1251", doc))
+	if err != nil {
+		t.Fatalf("Regular failed: %v", err)
+	}
+	if channel.Title != "Привет" {
+		t.Errorf("Expected Content-Type charset to take precedence, got %q", channel.Title)
+	}
+
+	// The BOM overrides the Content-Type charset and the XML declaration
+	doc = encodedFeed(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "ISO-8859-1", "Grüße")
+	channel, err = Regular(ctx, response("text/xml; charset=ISO-8859-1", doc))
+	if err != nil {
+		t.Fatalf("Regular failed: %v", err)
+	}
+	if channel.Title != "Grüße" {
+		t.Errorf("Expected BOM to take precedence, got %q", channel.Title)
+	}
+
+	// Unknown Content-Type charsets fall back to the XML declaration
+	doc = encodedFeed(t, charmap.ISO8859_15, "ISO-8859-15", "5 €")
+	channel, err = Regular(ctx, response("text/xml; charset=no-such-charset", doc))
+	if err != nil {
+		t.Fatalf("Regular failed: %v", err)
+	}
+	if channel.Title != "5 €" {
+		t.Errorf("Expected XML declaration to be used, got %q", channel.Title)
+	}
+}
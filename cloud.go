@@ -0,0 +1,387 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxXMLRPCSize is the maximum size of XML-RPC requests and responses.
+const maxXMLRPCSize = 1 << 20
+
+// ErrCloudProtocol is returned by RegisterCloud for clouds
+// with a protocol other than http-post or xml-rpc.
+var ErrCloudProtocol = errors.New("unsupported rssCloud protocol")
+
+// Cloud represents the cloud element of an RSS channel, which announces
+// an rssCloud server that notifies registered subscribers of channel updates.
+type Cloud struct {
+	// Domain is the host of the rssCloud server
+	Domain string `xml:"domain,attr" json:"domain,omitempty"`
+
+	// Port is the port of the rssCloud server as found in the feed
+	Port string `xml:"port,attr" json:"port,omitempty"`
+
+	// Path is the path of the rssCloud server
+	Path string `xml:"path,attr" json:"path,omitempty"`
+
+	// RegisterProcedure is the XML-RPC procedure to register with,
+	// usually empty for the http-post protocol
+	RegisterProcedure string `xml:"registerProcedure,attr" json:"registerProcedure,omitempty"`
+
+	// Protocol is "http-post", "xml-rpc" or "soap"
+	Protocol string `xml:"protocol,attr" json:"protocol,omitempty"`
+}
+
+// URL returns the URL of the rssCloud server,
+// using https if the port is 443 and http otherwise.
+func (c *Cloud) URL() string {
+	scheme := "http"
+	host := strings.TrimSpace(c.Domain)
+	switch port := strings.TrimSpace(c.Port); port {
+	case "", "80":
+	case "443":
+		scheme = "https"
+	default:
+		host = net.JoinHostPort(host, port)
+	}
+	path := strings.TrimSpace(c.Path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: scheme, Host: host, Path: path}).String()
+}
+
+// RegisterCloud registers notifyURL at the rssCloud server of the channel
+// to be notified of updates of the feed at feedURL. Notifications are requested
+// with the http-post protocol, or https-post if notifyURL is an https URL,
+// and can be received with a CloudHandler at notifyURL.
+// A nil client means http.DefaultClient.
+//
+// The server verifies that notifyURL is reachable before it accepts the registration.
+// Registrations expire after 25 hours and have to be renewed by calling
+// RegisterCloud again, for example every 24 hours.
+func RegisterCloud(ctx context.Context, client *http.Client, cloud *Cloud, feedURL, notifyURL string) error {
+	notify, err := url.Parse(notifyURL)
+	if err != nil || notify.Hostname() == "" || notify.Scheme != "http" && notify.Scheme != "https" {
+		return fmt.Errorf("invalid notify URL %q", notifyURL)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	// The port alone doesn't tell the server to use TLS
+	notifyProtocol, port := "http-post", notify.Port()
+	if notify.Scheme == "https" {
+		notifyProtocol = "https-post"
+		if port == "" {
+			port = "443"
+		}
+	} else if port == "" {
+		port = "80"
+	}
+	path := notify.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cloud.Protocol)) {
+	case "http-post":
+		form := url.Values{
+			"notifyProcedure": {""},
+			"port":            {port},
+			"path":            {path},
+			"protocol":        {notifyProtocol},
+			"domain":          {notify.Hostname()},
+			"url1":            {feedURL},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cloud.URL(), strings.NewReader(form.Encode()))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("user-agent", "go-rss/1.0.0")
+		resp, err := do(client, req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Success string `xml:"success,attr"`
+			Msg     string `xml:"msg,attr"`
+		}
+		if err := xml.NewDecoder(io.LimitReader(resp.Body, maxXMLRPCSize)).Decode(&result); err != nil {
+			return fmt.Errorf("failed to parse rssCloud response: %w", err)
+		}
+		if result.Success != "true" {
+			return fmt.Errorf("rssCloud registration failed: %s", result.Msg)
+		}
+		return nil
+
+	case "xml-rpc":
+		portNumber, _ := strconv.Atoi(port)
+		result, err := callXMLRPC(ctx, client, cloud.URL(), cloud.RegisterProcedure,
+			xmlrpcString(""),
+			xmlrpcInt(portNumber),
+			xmlrpcString(path),
+			xmlrpcString(notifyProtocol),
+			xmlrpcArray(xmlrpcString(feedURL)),
+			xmlrpcString(notify.Hostname()),
+		)
+		if err != nil {
+			return err
+		}
+		if !result.isTrue() {
+			return fmt.Errorf("rssCloud registration failed")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %q", ErrCloudProtocol, cloud.Protocol)
+	}
+}
+
+// PingWeblogUpdates sends an XML-RPC weblogUpdates.ping to a ping server like
+// rpc.pingomatic.com, announcing that the site has new content. If feedURL is not
+// empty, weblogUpdates.extendedPing is sent with the URL of the feed.
+// A nil client means http.DefaultClient.
+func PingWeblogUpdates(ctx context.Context, client *http.Client, pingURL, siteName, siteURL, feedURL string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	method := "weblogUpdates.ping"
+	params := []xmlrpcValue{xmlrpcString(siteName), xmlrpcString(siteURL)}
+	if feedURL != "" {
+		method = "weblogUpdates.extendedPing"
+		params = append(params, xmlrpcString(siteURL), xmlrpcString(feedURL))
+	}
+	result, err := callXMLRPC(ctx, client, pingURL, method, params...)
+	if err != nil {
+		return err
+	}
+	if result.member("flerror").isTrue() {
+		return fmt.Errorf("ping failed: %s", result.member("message").text())
+	}
+	return nil
+}
+
+// CloudHandler is an http.Handler receiving update notifications
+// of rssCloud servers and weblogUpdates pings. It handles:
+//
+//   - rssCloud http-post notifications, a POST with the feed URL as url parameter
+//   - rssCloud verification requests, a GET with a challenge parameter
+//   - XML-RPC weblogUpdates.ping and weblogUpdates.extendedPing calls
+//   - XML-RPC rssCloud notifications with the feed URL as first parameter
+type CloudHandler struct {
+	// OnUpdate is called with the URL of an updated feed, or of the
+	// updated site for weblogUpdates.ping. It is called before responding
+	// and should not block.
+	OnUpdate func(ctx context.Context, feedURL string)
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *CloudHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		challenge := r.URL.Query().Get("challenge")
+		if challenge == "" {
+			http.Error(w, "missing challenge", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, challenge)
+	case http.MethodPost:
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "text/xml" || mediaType == "application/xml" {
+			h.serveXMLRPC(w, r)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxXMLRPCSize)
+		feedURL := strings.TrimSpace(r.PostFormValue("url"))
+		if feedURL == "" {
+			http.Error(w, "missing url", http.StatusBadRequest)
+			return
+		}
+		h.update(r.Context(), feedURL)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CloudHandler) serveXMLRPC(w http.ResponseWriter, r *http.Request) {
+	var call xmlrpcCall
+	if err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxXMLRPCSize)).Decode(&call); err != nil {
+		writeXMLRPCResponse(w, xmlrpcFault(-32700, "failed to parse XML-RPC call"))
+		return
+	}
+
+	var result xmlrpcValue
+	switch call.MethodName {
+	case "weblogUpdates.ping", "weblogUpdates.extendedPing":
+		if len(call.Params) < 2 {
+			writeXMLRPCResponse(w, xmlrpcFault(-32602, "expected site name and URL"))
+			return
+		}
+		// extendedPing has the feed URL as fourth parameter
+		updated := call.Params[1].text()
+		if len(call.Params) >= 4 && call.Params[3].text() != "" {
+			updated = call.Params[3].text()
+		}
+		h.update(r.Context(), updated)
+		result = xmlrpcStruct(
+			xmlrpcMember{"flerror", xmlrpcBool(false)},
+			xmlrpcMember{"message", xmlrpcString("Thanks for the ping.")},
+		)
+	default:
+		if len(call.Params) < 1 || call.Params[0].text() == "" {
+			writeXMLRPCResponse(w, xmlrpcFault(-32602, "expected feed URL"))
+			return
+		}
+		h.update(r.Context(), call.Params[0].text())
+		result = xmlrpcBool(true)
+	}
+	writeXMLRPCResponse(w, &xmlrpcResponse{Params: []xmlrpcValue{result}})
+}
+
+func (h *CloudHandler) update(ctx context.Context, feedURL string) {
+	if h.OnUpdate != nil {
+		h.OnUpdate(ctx, feedURL)
+	}
+}
+
+// xmlrpcCall is an XML-RPC method call.
+type xmlrpcCall struct {
+	XMLName    xml.Name      `xml:"methodCall"`
+	MethodName string        `xml:"methodName"`
+	Params     []xmlrpcValue `xml:"params>param>value"`
+}
+
+// xmlrpcResponse is an XML-RPC method response with a result or a fault.
+type xmlrpcResponse struct {
+	XMLName xml.Name      `xml:"methodResponse"`
+	Params  []xmlrpcValue `xml:"params>param>value,omitempty"`
+	Fault   *xmlrpcValue  `xml:"fault>value,omitempty"`
+}
+
+// xmlrpcValue is an XML-RPC value of the types used by rssCloud and
+// weblogUpdates. Values without type element are strings.
+type xmlrpcValue struct {
+	Text    string         `xml:",chardata"`
+	String  *string        `xml:"string"`
+	Int     *string        `xml:"int"`
+	I4      *string        `xml:"i4"`
+	Boolean *string        `xml:"boolean"`
+	Array   []xmlrpcValue  `xml:"array>data>value"`
+	Members []xmlrpcMember `xml:"struct>member"`
+}
+
+type xmlrpcMember struct {
+	Name  string      `xml:"name"`
+	Value xmlrpcValue `xml:"value"`
+}
+
+func xmlrpcString(s string) xmlrpcValue {
+	return xmlrpcValue{String: &s}
+}
+
+func xmlrpcInt(n int) xmlrpcValue {
+	s := strconv.Itoa(n)
+	return xmlrpcValue{Int: &s}
+}
+
+func xmlrpcBool(b bool) xmlrpcValue {
+	s := "0"
+	if b {
+		s = "1"
+	}
+	return xmlrpcValue{Boolean: &s}
+}
+
+func xmlrpcArray(values ...xmlrpcValue) xmlrpcValue {
+	return xmlrpcValue{Array: values}
+}
+
+func xmlrpcStruct(members ...xmlrpcMember) xmlrpcValue {
+	return xmlrpcValue{Members: members}
+}
+
+func xmlrpcFault(code int, message string) *xmlrpcResponse {
+	fault := xmlrpcStruct(
+		xmlrpcMember{"faultCode", xmlrpcInt(code)},
+		xmlrpcMember{"faultString", xmlrpcString(message)},
+	)
+	return &xmlrpcResponse{Fault: &fault}
+}
+
+func (v xmlrpcValue) text() string {
+	for _, s := range []*string{v.String, v.Int, v.I4, v.Boolean} {
+		if s != nil {
+			return strings.TrimSpace(*s)
+		}
+	}
+	return strings.TrimSpace(v.Text)
+}
+
+func (v xmlrpcValue) isTrue() bool {
+	s := v.text()
+	return s == "1" || strings.EqualFold(s, "true")
+}
+
+// member returns the value of the struct member, or an empty value.
+func (v xmlrpcValue) member(name string) xmlrpcValue {
+	for _, member := range v.Members {
+		if member.Name == name {
+			return member.Value
+		}
+	}
+	return xmlrpcValue{}
+}
+
+// callXMLRPC calls the XML-RPC method at the URL and returns the result.
+func callXMLRPC(ctx context.Context, client *http.Client, rpcURL, method string, params ...xmlrpcValue) (xmlrpcValue, error) {
+	var body bytes.Buffer
+	if err := writeXML(&body, &xmlrpcCall{MethodName: method, Params: params}, xml.StartElement{}); err != nil {
+		return xmlrpcValue{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, &body)
+	if err != nil {
+		return xmlrpcValue{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	req.Header.Set("user-agent", "go-rss/1.0.0")
+	resp, err := do(client, req)
+	if err != nil {
+		return xmlrpcValue{}, err
+	}
+	defer resp.Body.Close()
+
+	var response xmlrpcResponse
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxXMLRPCSize)).Decode(&response); err != nil {
+		return xmlrpcValue{}, fmt.Errorf("failed to parse XML-RPC response: %w", err)
+	}
+	if response.Fault != nil {
+		return xmlrpcValue{}, fmt.Errorf("XML-RPC fault %s: %s",
+			response.Fault.member("faultCode").text(), response.Fault.member("faultString").text())
+	}
+	if len(response.Params) == 0 {
+		return xmlrpcValue{}, fmt.Errorf("XML-RPC response of %s has no result", method)
+	}
+	return response.Params[0], nil
+}
+
+// writeXMLRPCResponse writes the response, XML-RPC faults use the status 200 OK.
+func writeXMLRPCResponse(w http.ResponseWriter, response *xmlrpcResponse) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	writeXML(w, response, xml.StartElement{})
+}
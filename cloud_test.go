@@ -0,0 +1,255 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestParseCloud tests parsing the cloud element of RSS channels
+func TestParseCloud(t *testing.T) {
+	rssData := `<rss version="2.0"><channel>
+		<title>Cloud</title>
+		<cloud domain="rpc.example.com" port="8080" path="/RPC2" registerProcedure="myCloud.rssPleaseNotify" protocol="xml-rpc"/>
+		<item><title>Item</title></item>
+	</channel></rss>`
+	channel, err := ParseRegular(context.Background(), strings.NewReader(rssData))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	expected := Cloud{Domain: "rpc.example.com", Port: "8080", Path: "/RPC2", RegisterProcedure: "myCloud.rssPleaseNotify", Protocol: "xml-rpc"}
+	if channel.Cloud == nil || *channel.Cloud != expected {
+		t.Fatalf("Expected cloud %+v, got %+v", expected, channel.Cloud)
+	}
+	if u := channel.Cloud.URL(); u != "http://rpc.example.com:8080/RPC2" {
+		t.Errorf("Unexpected cloud URL %q", u)
+	}
+	if u := (&Cloud{Domain: "example.com", Port: "80", Path: "rsscloud/pleaseNotify"}).URL(); u != "http://example.com/rsscloud/pleaseNotify" {
+		t.Errorf("Unexpected cloud URL %q", u)
+	}
+	if u := (&Cloud{Domain: "example.com", Port: "443", Path: "/rsscloud/pleaseNotify"}).URL(); u != "https://example.com/rsscloud/pleaseNotify" {
+		t.Errorf("Unexpected cloud URL %q", u)
+	}
+}
+
+// updateRecorder records the feed URLs of CloudHandler updates
+type updateRecorder struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (r *updateRecorder) onUpdate(ctx context.Context, feedURL string) {
+	r.mu.Lock()
+	r.urls = append(r.urls, feedURL)
+	r.mu.Unlock()
+}
+
+func (r *updateRecorder) last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.urls) == 0 {
+		return ""
+	}
+	return r.urls[len(r.urls)-1]
+}
+
+// cloudServer returns the host and port of the server URL as cloud
+func cloudServer(t *testing.T, serverURL, protocol string) *Cloud {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Cloud{Domain: u.Hostname(), Port: u.Port(), Path: "/rsscloud", RegisterProcedure: "rssCloud.pleaseNotify", Protocol: protocol}
+}
+
+// TestRegisterCloudHTTPPost tests the http-post registration
+// with a local stand-in rssCloud server
+func TestRegisterCloudHTTPPost(t *testing.T) {
+	var recorder updateRecorder
+	notifyServer := httptest.NewServer(&CloudHandler{OnUpdate: recorder.onUpdate})
+	defer notifyServer.Close()
+
+	cloudHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.URL.Path != "/rsscloud" || r.PostForm.Get("protocol") != "http-post" {
+			io.WriteString(w, `<notifyResult success="false" msg="bad request"/>`)
+			return
+		}
+		// Verify the subscriber with a challenge like rssCloud servers do
+		notifyURL := "http://" + r.PostForm.Get("domain") + ":" + r.PostForm.Get("port") + r.PostForm.Get("path")
+		resp, err := http.Get(notifyURL + "?url=" + url.QueryEscape(r.PostForm.Get("url1")) + "&challenge=abc")
+		if err != nil {
+			io.WriteString(w, `<notifyResult success="false" msg="unreachable"/>`)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "abc" {
+			io.WriteString(w, `<notifyResult success="false" msg="wrong challenge"/>`)
+			return
+		}
+		// Notify the subscriber of an update
+		resp, err = http.PostForm(notifyURL, url.Values{"url": {r.PostForm.Get("url1")}})
+		if err == nil {
+			resp.Body.Close()
+		}
+		io.WriteString(w, `<notifyResult success="true" msg="Registration successful."/>`)
+	})
+	cloud := httptest.NewServer(cloudHandler)
+	defer cloud.Close()
+
+	err := RegisterCloud(context.Background(), cloud.Client(), cloudServer(t, cloud.URL, "http-post"), "https://example.com/feed.xml", notifyServer.URL+"/notify")
+	if err != nil {
+		t.Fatalf("RegisterCloud failed: %v", err)
+	}
+	if last := recorder.last(); last != "https://example.com/feed.xml" {
+		t.Errorf("Expected update notification, got %q", last)
+	}
+
+	// Registrations refused by the server are errors
+	err = RegisterCloud(context.Background(), cloud.Client(), cloudServer(t, cloud.URL, "http-post"), "https://example.com/feed.xml", "http://unreachable.invalid/notify")
+	if err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("Expected registration error, got %v", err)
+	}
+
+	err = RegisterCloud(context.Background(), cloud.Client(), cloudServer(t, cloud.URL, "soap"), "https://example.com/feed.xml", notifyServer.URL)
+	if !errors.Is(err, ErrCloudProtocol) {
+		t.Errorf("Expected ErrCloudProtocol, got %v", err)
+	}
+}
+
+// TestRegisterCloudXMLRPC tests the xml-rpc registration
+func TestRegisterCloudXMLRPC(t *testing.T) {
+	var call xmlrpcCall
+	cloud := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := xml.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `<?xml version="1.0"?><methodResponse><params><param><value><boolean>1</boolean></value></param></params></methodResponse>`)
+	}))
+	defer cloud.Close()
+
+	// A nil client means http.DefaultClient
+	err := RegisterCloud(context.Background(), nil, cloudServer(t, cloud.URL, "xml-rpc"), "https://example.com/feed.xml", "https://subscriber.example.com/notify")
+	if err != nil {
+		t.Fatalf("RegisterCloud failed: %v", err)
+	}
+	if call.MethodName != "rssCloud.pleaseNotify" || len(call.Params) != 6 {
+		t.Fatalf("Unexpected call %+v", call)
+	}
+	if port := call.Params[1].text(); port != "443" {
+		t.Errorf("Expected port 443, got %q", port)
+	}
+	if path, protocol := call.Params[2].text(), call.Params[3].text(); path != "/notify" || protocol != "https-post" {
+		t.Errorf("Unexpected path %q and protocol %q", path, protocol)
+	}
+	if urls := call.Params[4].Array; len(urls) != 1 || urls[0].text() != "https://example.com/feed.xml" {
+		t.Errorf("Unexpected URL list %+v", urls)
+	}
+	if domain := call.Params[5].text(); domain != "subscriber.example.com" {
+		t.Errorf("Unexpected domain %q", domain)
+	}
+}
+
+// TestRegisterCloudHTTPS tests the registration at a server on port 443
+// and of an https notify URL
+func TestRegisterCloudHTTPS(t *testing.T) {
+	var (
+		requestURL string
+		form       url.Values
+	)
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requestURL = req.URL.String()
+		req.ParseForm()
+		form = req.PostForm
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/xml"}},
+			Body:       io.NopCloser(strings.NewReader(`<notifyResult success="true" msg="Registration successful."/>`)),
+		}, nil
+	})}
+	cloud := &Cloud{Domain: "cloud.example.com", Port: "443", Path: "/pleaseNotify", Protocol: "http-post"}
+
+	err := RegisterCloud(context.Background(), client, cloud, "https://example.com/feed.xml", "https://subscriber.example.com/notify")
+	if err != nil {
+		t.Fatalf("RegisterCloud failed: %v", err)
+	}
+	if requestURL != "https://cloud.example.com/pleaseNotify" {
+		t.Errorf("Expected https request, got %q", requestURL)
+	}
+	if form.Get("protocol") != "https-post" || form.Get("port") != "443" || form.Get("domain") != "subscriber.example.com" {
+		t.Errorf("Unexpected registration %v", form)
+	}
+
+	if err := RegisterCloud(context.Background(), client, cloud, "https://example.com/feed.xml", "ftp://subscriber.example.com/notify"); err == nil {
+		t.Error("Expected error for ftp notify URL")
+	}
+}
+
+// TestCloudHandler tests receiving rssCloud notifications and weblogUpdates pings
+func TestCloudHandler(t *testing.T) {
+	var recorder updateRecorder
+	server := httptest.NewServer(&CloudHandler{OnUpdate: recorder.onUpdate})
+	defer server.Close()
+	ctx := context.Background()
+
+	if err := PingWeblogUpdates(ctx, nil, server.URL, "Blog", "https://blog.example.com/", ""); err != nil {
+		t.Fatalf("PingWeblogUpdates failed: %v", err)
+	}
+	if last := recorder.last(); last != "https://blog.example.com/" {
+		t.Errorf("Expected site URL for ping, got %q", last)
+	}
+	if err := PingWeblogUpdates(ctx, server.Client(), server.URL, "Blog", "https://blog.example.com/", "https://blog.example.com/feed"); err != nil {
+		t.Fatalf("PingWeblogUpdates failed: %v", err)
+	}
+	if last := recorder.last(); last != "https://blog.example.com/feed" {
+		t.Errorf("Expected feed URL for extended ping, got %q", last)
+	}
+
+	// rssCloud XML-RPC notification with an untyped string parameter
+	resp, err := http.Post(server.URL, "text/xml", strings.NewReader(`<?xml version="1.0"?>
+		<methodCall><methodName>myNotify</methodName><params><param><value>https://other.example.com/rss</value></param></params></methodCall>`))
+	if err != nil {
+		t.Fatalf("Failed to send notification: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "<boolean>1</boolean>") || recorder.last() != "https://other.example.com/rss" {
+		t.Errorf("Unexpected response %s for %q", body, recorder.last())
+	}
+
+	// Invalid calls are answered with a fault
+	_, err = callXMLRPC(ctx, server.Client(), server.URL, "weblogUpdates.ping", xmlrpcString("Blog"))
+	if err == nil || !strings.Contains(err.Error(), "expected site name and URL") {
+		t.Errorf("Expected fault, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	(&CloudHandler{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without url, got %d", rec.Code)
+	}
+}
+
+// TestPingWeblogUpdatesError tests pings rejected by the ping server
+func TestPingWeblogUpdatesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<?xml version="1.0"?><methodResponse><params><param><value><struct>
+			<member><name>flerror</name><value><boolean>1</boolean></value></member>
+			<member><name>message</name><value>Site is blocked</value></member>
+		</struct></value></param></params></methodResponse>`)
+	}))
+	defer server.Close()
+
+	err := PingWeblogUpdates(context.Background(), server.Client(), server.URL, "Blog", "https://blog.example.com/", "")
+	if err == nil || !strings.Contains(err.Error(), "Site is blocked") {
+		t.Errorf("Expected ping error, got %v", err)
+	}
+}
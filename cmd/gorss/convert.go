@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ungerik/go-rss"
+)
+
+// runConvert converts a feed to another format.
+func runConvert(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "convert", "<feed>")
+	to := f.String("to", "", "target format: rss, atom or json")
+	output := f.String("o", "", "output file, standard output if empty")
+	if err := f.parse(args, 1, 1); err != nil {
+		return err
+	}
+	format := rss.Format(*to)
+	switch format {
+	case rss.FormatRSS, rss.FormatAtom, rss.FormatJSON:
+	default:
+		return &usageError{fmt.Sprintf("invalid target format %q, must be rss, atom or json", *to)}
+	}
+
+	parsed, err := f.parseFeed(ctx, f.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	w, err := f.create(*output)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case rss.FormatRSS:
+		err = rss.WriteRegular(w, parsed.ToRegular())
+	case rss.FormatAtom:
+		err = rss.WriteAtom(w, parsed.ToAtom())
+	case rss.FormatJSON:
+		err = rss.WriteJSONFeed(w, parsed.ToJSONFeed())
+	}
+	return errors.Join(err, w.Close())
+}
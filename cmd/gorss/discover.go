@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/ungerik/go-rss"
+)
+
+// runDiscover prints the feeds linked by a web page
+// and fails if the page links no feeds.
+func runDiscover(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "discover", "<page URL>")
+	if err := f.parse(args, 1, 1); err != nil {
+		return err
+	}
+
+	feeds, err := rss.Discover(ctx, f.Arg(0), f.client())
+	if err != nil {
+		return err
+	}
+	if len(feeds) == 0 {
+		return errors.New("no feeds found")
+	}
+
+	w := tabwriter.NewWriter(f.stdout, 0, 0, 2, ' ', 0)
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", feed.URL, feed.Format, feed.Title)
+	}
+	return w.Flush()
+}
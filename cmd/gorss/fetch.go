@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ungerik/go-rss"
+)
+
+// runFetch prints the items of a feed as table or JSON Feed.
+func runFetch(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "fetch", "<feed>")
+	asJSON := f.Bool("json", false, "print the feed as JSON Feed")
+	limit := f.Int("n", 0, "maximum number of items to print, 0 for all")
+	if err := f.parse(args, 1, 1); err != nil {
+		return err
+	}
+
+	parsed, err := f.parseFeed(ctx, f.Arg(0))
+	if err != nil {
+		return err
+	}
+	feed := parsed.ToJSONFeed()
+	if *limit > 0 && len(feed.Items) > *limit {
+		feed.Items = feed.Items[:*limit]
+	}
+
+	if *asJSON {
+		return rss.WriteJSONFeed(f.stdout, feed)
+	}
+	return printItems(f.stdout, feed)
+}
+
+// printItems prints the items of the feed as table.
+func printItems(out io.Writer, feed *rss.JSONFeed) error {
+	fmt.Fprintf(out, "%s (%d items)\n\n", feed.Title, len(feed.Items))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tTITLE\tURL")
+	for _, item := range feed.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", formatDate(item.DatePublished), truncate(item.Title, 80), item.URL)
+	}
+	return w.Flush()
+}
+
+// formatDate formats an RFC 3339 date for tables,
+// other dates are returned as they are.
+func formatDate(date string) string {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
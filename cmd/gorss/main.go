@@ -0,0 +1,237 @@
+// Command gorss fetches, inspects, validates and converts RSS, Atom and JSON feeds.
+//
+// Usage:
+//
+//	gorss <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	fetch     print the items of a feed as table or JSON
+//	validate  check a feed for conformance with the RSS 2.0 or Atom 1.0 spec
+//	convert   convert a feed to RSS, Atom or JSON Feed
+//	discover  find the feeds linked by a web page
+//	opml      import, export or refresh OPML subscription lists
+//	watch     print new items of feeds as they appear
+//
+// Feeds can be given as http(s) URL, as file path or as "-" for standard input.
+// Run "gorss <command> -h" for the flags of a command.
+//
+// Exit codes:
+//
+//	0  success
+//	1  the command failed, for example a feed could not be fetched,
+//	   has validation errors or a page links no feeds
+//	2  invalid command line usage
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/ungerik/go-rss"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// errFailed signals a failure that was already reported to the user.
+var errFailed = errors.New("failed")
+
+// usageError is returned by commands for invalid command line arguments.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// env holds the standard streams of a command.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a subcommand of gorss.
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = []command{
+	{"fetch", "print the items of a feed as table or JSON", runFetch},
+	{"validate", "check a feed for conformance with the RSS 2.0 or Atom 1.0 spec", runValidate},
+	{"convert", "convert a feed to RSS, Atom or JSON Feed", runConvert},
+	{"discover", "find the feeds linked by a web page", runDiscover},
+	{"opml", "import, export or refresh OPML subscription lists", runOPML},
+	{"watch", "print new items of feeds as they appear", runWatch},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command line and returns the exit code.
+func run(ctx context.Context, e *env, args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		printUsage(e.stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(ctx, e, args[1:])
+		var usageErr *usageError
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usageErr):
+			fmt.Fprintf(e.stderr, "gorss %s: %s\n", cmd.name, usageErr.msg)
+			return exitUsage
+		case errors.Is(err, errFailed):
+			return exitFailure
+		default:
+			fmt.Fprintf(e.stderr, "gorss %s: %s\n", cmd.name, err)
+			return exitFailure
+		}
+	}
+	fmt.Fprintf(e.stderr, "gorss: unknown command %q\n", args[0])
+	printUsage(e.stderr)
+	return exitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gorss <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "gorss <command> -h" for the flags of a command.`)
+}
+
+// flags are the flags shared by all commands
+// and the environment of the command.
+type flags struct {
+	*flag.FlagSet
+	*env
+	timeout time.Duration
+}
+
+// newFlags returns a flag set for the command with the shared flags.
+func newFlags(e *env, name, arguments string) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError), env: e}
+	f.SetOutput(e.stderr)
+	f.DurationVar(&f.timeout, "timeout", 30*time.Second, "timeout of HTTP requests")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: gorss %s [flags] %s\n\nFlags:\n", name, arguments)
+		f.PrintDefaults()
+	}
+	return f
+}
+
+// parse parses the arguments and checks the number of positional arguments,
+// maxArgs < 0 means any number.
+func (f *flags) parse(args []string, minArgs, maxArgs int) error {
+	if err := f.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{err.Error()}
+	}
+	if n := f.NArg(); n < minArgs || maxArgs >= 0 && n > maxArgs {
+		f.Usage()
+		return &usageError{fmt.Sprintf("expected %s arguments, got %d", argCount(minArgs, maxArgs), n)}
+	}
+	return nil
+}
+
+func argCount(minArgs, maxArgs int) string {
+	switch {
+	case maxArgs < 0:
+		return fmt.Sprintf("at least %d", minArgs)
+	case minArgs == maxArgs:
+		return fmt.Sprint(minArgs)
+	}
+	return fmt.Sprintf("%d to %d", minArgs, maxArgs)
+}
+
+func (f *flags) client() *http.Client {
+	return &http.Client{Timeout: f.timeout}
+}
+
+// isURL checks if source is an http or https URL.
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// open returns a response for a feed source, which is an http(s) URL,
+// a file path or "-" for standard input.
+func (f *flags) open(ctx context.Context, source string) (*http.Response, error) {
+	if isURL(source) {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		reddit := u.Hostname() == "reddit.com" || strings.HasSuffix(u.Hostname(), ".reddit.com")
+		return rss.ReadWithClient(ctx, source, f.client(), reddit)
+	}
+
+	body := io.NopCloser(f.stdin)
+	if source != "-" {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		body = file
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body}, nil
+}
+
+// parseFeed fetches and parses the feed of a source, see open.
+func (f *flags) parseFeed(ctx context.Context, source string) (*rss.ParsedFeed, error) {
+	resp, err := f.open(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := rss.ParseResponse(ctx, resp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return parsed, nil
+}
+
+// create returns the output file, or standard output if path is empty or "-".
+func (f *flags) create(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{f.stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+	<channel>
+		<title>Test Feed</title>
+		<link>https://example.com/</link>
+		<description>A feed for testing</description>
+		<item>
+			<title>Second Post</title>
+			<link>https://example.com/2</link>
+			<guid>https://example.com/2</guid>
+			<pubDate>Tue, 02 Jan 2024 12:00:00 GMT</pubDate>
+		</item>
+		<item>
+			<title>First Post</title>
+			<link>https://example.com/1</link>
+			<guid>https://example.com/1</guid>
+			<pubDate>Mon, 01 Jan 2024 12:00:00 GMT</pubDate>
+		</item>
+	</channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:test</id>
+	<title>Atom Feed</title>
+	<updated>2024-01-01T12:00:00Z</updated>
+	<entry>
+		<id>urn:test:1</id>
+		<title>Atom Entry</title>
+		<updated>2024-01-01T12:00:00Z</updated>
+		<link href="https://example.com/atom/1"/>
+	</entry>
+</feed>`
+
+// testServer serves the test feeds and pages for discovery.
+func testServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.rss", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(testFeed))
+	})
+	mux.HandleFunc("/feed.atom", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(testAtomFeed))
+	})
+	mux.HandleFunc("/invalid.rss", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss version="2.0"><channel><title>Invalid</title><item><link>/relative</link></item></channel></rss>`))
+	})
+	mux.HandleFunc("/episodes.rss", func(w http.ResponseWriter, r *http.Request) {
+		// Items without GUID and link are identified by title, date and enclosure
+		w.Write([]byte(`<rss version="2.0"><channel><title>Episodes</title>
+			<item><title>Weekly</title><pubDate>Tue, 09 Jan 2024 12:00:00 GMT</pubDate><enclosure url="https://example.com/2.mp3" type="audio/mpeg" length="1"/></item>
+			<item><title>Weekly</title><pubDate>Tue, 02 Jan 2024 12:00:00 GMT</pubDate><enclosure url="https://example.com/1.mp3" type="audio/mpeg" length="1"/></item>
+		</channel></rss>`))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/rss+xml" title="Posts" href="/feed.rss"></head></html>`))
+	})
+	mux.HandleFunc("/nofeeds", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Nothing</title></head></html>`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestRun tests the exit codes and output of the commands
+func TestRun(t *testing.T) {
+	server := testServer(t)
+	dir := t.TempDir()
+	opmlFile := filepath.Join(dir, "feeds.opml")
+	opmlData := `<opml version="2.0"><head><title>Feeds</title></head><body>
+		<outline text="Folder"><outline text="Old Title" type="rss" xmlUrl="` + server.URL + `/feed.rss"/></outline>
+	</body></opml>`
+	if err := os.WriteFile(opmlFile, []byte(opmlData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		stdin    string
+		code     int
+		stdout   []string
+		excluded []string
+		stderr   string
+	}{
+		{name: "no command", args: nil, code: exitUsage, stderr: "Usage: gorss"},
+		{name: "help", args: []string{"help"}, code: exitOK, stderr: "Commands:"},
+		{name: "unknown command", args: []string{"foo"}, code: exitUsage, stderr: `unknown command "foo"`},
+
+		{name: "fetch", args: []string{"fetch", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{"Test Feed (2 items)", "First Post", "https://example.com/2"}},
+		{name: "fetch json", args: []string{"fetch", "-json", "-n", "1", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{`"version": "https://jsonfeed.org/version/1.1"`, `"title": "Second Post"`}, excluded: []string{"First Post"}},
+		{name: "fetch stdin", args: []string{"fetch", "-"}, stdin: testAtomFeed, code: exitOK, stdout: []string{"Atom Feed (1 items)", "Atom Entry"}},
+		{name: "fetch file", args: []string{"fetch", "-n", "1", filepath.Join("..", "..", "testdata", "podcast.rss")}, code: exitOK, stdout: []string{"(1 items)"}},
+		{name: "fetch help", args: []string{"fetch", "-h"}, code: exitOK, stderr: "Usage: gorss fetch [flags] <feed>"},
+		{name: "fetch without feed", args: []string{"fetch"}, code: exitUsage, stderr: "expected 1 arguments, got 0"},
+		{name: "fetch not found", args: []string{"fetch", server.URL + "/missing"}, code: exitFailure, stderr: "HTTP 404"},
+		{name: "fetch HTML page", args: []string{"fetch", server.URL + "/page"}, code: exitFailure, stderr: "HTML"},
+
+		{name: "convert to atom", args: []string{"convert", "-to", "atom", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{`<feed xmlns="http://www.w3.org/2005/Atom">`, "<title>First Post</title>"}},
+		{name: "convert to rss", args: []string{"convert", "-to", "rss", server.URL + "/feed.atom"}, code: exitOK, stdout: []string{`<rss version="2.0">`, "<title>Atom Entry</title>"}},
+		{name: "convert to json", args: []string{"convert", "-to", "json", "-"}, stdin: testFeed, code: exitOK, stdout: []string{`"title": "Test Feed"`}},
+		{name: "convert invalid format", args: []string{"convert", "-to", "yaml", server.URL + "/feed.rss"}, code: exitUsage, stderr: `invalid target format "yaml"`},
+
+		{name: "validate", args: []string{"validate", "-q", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{"valid"}},
+		{name: "validate errors", args: []string{"validate", server.URL + "/invalid.rss"}, code: exitFailure, stdout: []string{"missing required element <link>", "relative URL"}},
+
+		{name: "discover", args: []string{"discover", server.URL + "/page"}, code: exitOK, stdout: []string{server.URL + "/feed.rss", "rss", "Posts"}},
+		{name: "discover feed", args: []string{"discover", server.URL + "/feed.atom"}, code: exitOK, stdout: []string{server.URL + "/feed.atom", "atom"}},
+		{name: "discover no feeds", args: []string{"discover", server.URL + "/nofeeds"}, code: exitFailure, stderr: "no feeds found"},
+
+		{name: "opml without subcommand", args: []string{"opml"}, code: exitUsage, stderr: "expected subcommand"},
+		{name: "opml import", args: []string{"opml", "import", opmlFile}, code: exitOK, stdout: []string{server.URL + "/feed.rss", "Old Title"}},
+		{name: "opml export", args: []string{"opml", "export", "-title", "Export", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{`<opml version="2.0">`, "<title>Export</title>", `text="Test Feed"`, `htmlUrl="https://example.com/"`}},
+		{name: "opml export failed feed", args: []string{"opml", "export", server.URL + "/feed.rss", server.URL + "/missing"}, code: exitFailure, stdout: []string{`text="Test Feed"`}, stderr: "HTTP 404"},
+		{name: "opml refresh", args: []string{"opml", "refresh", opmlFile}, code: exitOK, stdout: []string{`text="Folder"`, `text="Test Feed"`}, excluded: []string{"Old Title"}},
+
+		{name: "watch", args: []string{"watch", "-n", "1", "-interval", "1h", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{"Test Feed: Second Post  https://example.com/2"}, excluded: []string{"First Post"}},
+		{name: "watch json", args: []string{"watch", "-n", "2", "-json", server.URL + "/feed.rss"}, code: exitOK, stdout: []string{`{"feed":"Test Feed","id":"https://example.com/1"`, `"title":"Second Post"`}},
+		{name: "watch items with same title", args: []string{"watch", "-n", "2", "-json", server.URL + "/episodes.rss"}, code: exitOK, stdout: []string{"https://example.com/1.mp3", "https://example.com/2.mp3"}},
+		{name: "watch invalid interval", args: []string{"watch", "-interval", "0s", server.URL + "/feed.rss"}, code: exitUsage, stderr: "interval must be positive"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// watch runs until the context is canceled
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			var stdout, stderr bytes.Buffer
+			e := &env{stdin: strings.NewReader(tc.stdin), stdout: &stdout, stderr: &stderr}
+
+			if code := run(ctx, e, tc.args); code != tc.code {
+				t.Errorf("Expected exit code %d, got %d, stderr:\n%s", tc.code, code, stderr.String())
+			}
+			for _, s := range tc.stdout {
+				if !strings.Contains(stdout.String(), s) {
+					t.Errorf("Expected %q in output:\n%s", s, stdout.String())
+				}
+			}
+			for _, s := range tc.excluded {
+				if strings.Contains(stdout.String(), s) {
+					t.Errorf("Unexpected %q in output:\n%s", s, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), tc.stderr) {
+				t.Errorf("Expected %q in error output:\n%s", tc.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+// TestRunOutputFile tests that -o writes to a file instead of standard output
+func TestRunOutputFile(t *testing.T) {
+	server := testServer(t)
+	path := filepath.Join(t.TempDir(), "feed.json")
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+
+	if code := run(context.Background(), e, []string{"convert", "-to", "json", "-o", path, server.URL + "/feed.rss"}); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d, stderr:\n%s", code, stderr.String())
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("Expected no output, got:\n%s", stdout.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"title": "First Post"`) {
+		t.Errorf("Unexpected file content:\n%s", data)
+	}
+}
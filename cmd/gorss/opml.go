@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/ungerik/go-rss"
+)
+
+// runOPML runs the opml subcommands import, export and refresh.
+func runOPML(ctx context.Context, e *env, args []string) error {
+	usage := &usageError{"expected subcommand import <file>, export <feed>... or refresh <file>"}
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "import":
+		return runOPMLImport(ctx, e, args[1:])
+	case "export":
+		return runOPMLExport(ctx, e, args[1:])
+	case "refresh":
+		return runOPMLRefresh(ctx, e, args[1:])
+	}
+	return usage
+}
+
+// runOPMLImport prints the feeds of an OPML file.
+func runOPMLImport(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "opml import", "<file>")
+	if err := f.parse(args, 1, 1); err != nil {
+		return err
+	}
+	opml, err := readOPML(ctx, f, f.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(f.stdout, 0, 0, 2, ' ', 0)
+	for _, outline := range opml.Feeds() {
+		fmt.Fprintf(w, "%s\t%s\n", outline.XMLURL, outline.Text)
+	}
+	return w.Flush()
+}
+
+// runOPMLExport writes an OPML file with the given feeds,
+// fetching them to get their titles and website URLs.
+func runOPMLExport(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "opml export", "<feed URL>...")
+	title := f.String("title", "Subscriptions", "title of the OPML document")
+	output := f.String("o", "", "output file, standard output if empty")
+	if err := f.parse(args, 1, -1); err != nil {
+		return err
+	}
+
+	var (
+		outlines []rss.Outline
+		failed   bool
+	)
+	for _, feedURL := range f.Args() {
+		outline := rss.FeedOutline(feedURL, feedURL, "")
+		if err := refreshOutline(ctx, f, &outline); err != nil {
+			fmt.Fprintln(f.stderr, err)
+			failed = true
+		}
+		outlines = append(outlines, outline)
+	}
+
+	if err := writeOPML(f, rss.NewOPML(*title, outlines...), *output); err != nil {
+		return err
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// runOPMLRefresh fetches all feeds of an OPML file, updates their
+// titles and website URLs and reports the feeds that failed.
+func runOPMLRefresh(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "opml refresh", "<file>")
+	output := f.String("o", "", "output file for the refreshed OPML, standard output if empty")
+	if err := f.parse(args, 1, 1); err != nil {
+		return err
+	}
+	opml, err := readOPML(ctx, f, f.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	var refresh func(outlines []rss.Outline)
+	refresh = func(outlines []rss.Outline) {
+		for i := range outlines {
+			if outlines[i].XMLURL != "" {
+				if err := refreshOutline(ctx, f, &outlines[i]); err != nil {
+					fmt.Fprintln(f.stderr, err)
+					failed++
+				}
+			}
+			refresh(outlines[i].Outlines)
+		}
+	}
+	refresh(opml.Body.Outlines)
+
+	if err := writeOPML(f, opml, *output); err != nil {
+		return err
+	}
+	if failed > 0 {
+		fmt.Fprintf(f.stderr, "%d of %d feeds failed\n", failed, len(opml.Feeds()))
+		return errFailed
+	}
+	return nil
+}
+
+// refreshOutline fetches the feed of the outline and updates its title and website URL.
+func refreshOutline(ctx context.Context, f *flags, outline *rss.Outline) error {
+	parsed, err := f.parseFeed(ctx, outline.XMLURL)
+	if err != nil {
+		return err
+	}
+	channel := parsed.ToRegular()
+	if channel.Title != "" {
+		outline.Text = channel.Title
+		outline.Title = channel.Title
+	}
+	if channel.Link != "" {
+		outline.HTMLURL = channel.Link
+	}
+	outline.Type = "rss"
+	return nil
+}
+
+func readOPML(ctx context.Context, f *flags, source string) (*rss.OPML, error) {
+	resp, err := f.open(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	opml, err := rss.ParseOPML(ctx, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OPML: %w", err)
+	}
+	return opml, nil
+}
+
+func writeOPML(f *flags, opml *rss.OPML, path string) error {
+	w, err := f.create(path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(opml.Write(w), w.Close())
+}
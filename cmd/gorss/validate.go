@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ungerik/go-rss"
+)
+
+// runValidate prints the conformance issues of a feed and fails
+// if there are errors, or warnings with -strict.
+func runValidate(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "validate", "<feed>")
+	strict := f.Bool("strict", false, "fail on warnings too")
+	quiet := f.Bool("q", false, "don't print info issues")
+	if err := f.parse(args, 1, 1); err != nil {
+		return err
+	}
+
+	resp, err := f.open(ctx, f.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	issues, err := rss.Validate(ctx, resp.Body)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if *quiet && issue.Severity == rss.SeverityInfo {
+			continue
+		}
+		fmt.Fprintln(f.stdout, issue)
+	}
+
+	severity, ok := rss.MaxSeverity(issues)
+	switch {
+	case !ok:
+		fmt.Fprintln(f.stdout, "valid")
+	case severity == rss.SeverityError, *strict && severity == rss.SeverityWarning:
+		return errFailed
+	}
+	return nil
+}
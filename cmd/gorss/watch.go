@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ungerik/go-rss"
+)
+
+// runWatch polls feeds and prints new items until interrupted.
+func runWatch(ctx context.Context, e *env, args []string) error {
+	f := newFlags(e, "watch", "<feed>...")
+	interval := f.Duration("interval", 5*time.Minute, "polling interval")
+	initial := f.Int("n", 0, "number of existing items to print per feed at start")
+	asJSON := f.Bool("json", false, "print items as JSON lines")
+	state := f.String("state", "", "file recording seen items across restarts")
+	if err := f.parse(args, 1, -1); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return &usageError{"interval must be positive"}
+	}
+
+	var store rss.SeenStore = rss.NewMemorySeenStore(0)
+	if *state != "" {
+		fileStore, err := rss.NewFileSeenStore(*state, stateTTL)
+		if err != nil {
+			return err
+		}
+		defer fileStore.Close()
+		if err := fileStore.Prune(ctx); err != nil {
+			return err
+		}
+		store = fileStore
+	}
+
+	poll := func(first bool) {
+		for _, source := range f.Args() {
+			parsed, err := f.parseFeed(ctx, source)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintln(f.stderr, err)
+				}
+				continue
+			}
+			feed := parsed.ToJSONFeed()
+			keys := itemKeys(parsed)
+			seen, err := store.Seen(ctx, source, keys)
+			if err != nil {
+				fmt.Fprintln(f.stderr, err)
+				continue
+			}
+			// Only the -n newest items of feeds watched for the first time are new
+			known := len(seen) > 0
+
+			var newItems []rss.JSONFeedItem
+			var newKeys []string
+			for i, item := range feed.Items {
+				if seen[keys[i]] {
+					continue
+				}
+				seen[keys[i]] = true
+				newKeys = append(newKeys, keys[i])
+				if !first || known || i < *initial {
+					newItems = append(newItems, item)
+				}
+			}
+			if first {
+				// Refresh the items that are still in the feed
+				newKeys = keys
+			}
+			if err := store.Mark(ctx, source, newKeys); err != nil {
+				fmt.Fprintln(f.stderr, err)
+			}
+			// Feeds list the newest items first, print them in chronological order
+			for i := len(newItems) - 1; i >= 0; i-- {
+				printWatchedItem(f.env, feed, newItems[i], *asJSON)
+			}
+		}
+	}
+
+	poll(true)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			poll(false)
+		}
+	}
+}
+
+// stateTTL is how long items are remembered in the -state file,
+// items still in a feed are refreshed at every start.
+const stateTTL = 30 * 24 * time.Hour
+
+// itemKeys returns the keys identifying the items of the feed between polls,
+// see rss.Item.Key and rss.Entry.Key, in the order of the items of its JSON Feed.
+func itemKeys(parsed *rss.ParsedFeed) []string {
+	if parsed.Format == rss.FormatAtom {
+		keys := make([]string, len(parsed.Feed.Entry))
+		for i := range parsed.Feed.Entry {
+			keys[i] = parsed.Feed.Entry[i].Key()
+		}
+		return keys
+	}
+	channel := parsed.ToRegular()
+	keys := make([]string, len(channel.Item))
+	for i := range channel.Item {
+		keys[i] = channel.Item[i].Key()
+	}
+	return keys
+}
+
+func printWatchedItem(e *env, feed *rss.JSONFeed, item rss.JSONFeedItem, asJSON bool) {
+	if !asJSON {
+		fmt.Fprintf(e.stdout, "%s  %s: %s  %s\n", formatDate(item.DatePublished), feed.Title, item.Title, item.URL)
+		return
+	}
+	line, err := json.Marshal(struct {
+		Feed string `json:"feed"`
+		rss.JSONFeedItem
+	}{feed.Title, item})
+	if err != nil {
+		fmt.Fprintln(e.stderr, err)
+		return
+	}
+	fmt.Fprintln(e.stdout, string(line))
+}
@@ -0,0 +1,300 @@
+package rss
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Time returns the publication time of the item, which is PubTime if the item
+// was parsed, otherwise PubDate is parsed. Returns the zero time if the item
+// has no valid date.
+func (item *Item) Time() time.Time {
+	if !item.PubTime.Time.IsZero() {
+		return item.PubTime.Time
+	}
+	t, _ := item.PubDate.Parse()
+	return t
+}
+
+// Time returns the publication time of the entry, falling back to the time
+// of the last update if the entry has no published date, see Item.Time.
+// Returns the zero time if the entry has no valid date.
+func (e *Entry) Time() time.Time {
+	for _, dt := range []DateTime{e.PublishedTime, e.UpdatedTime} {
+		if !dt.Time.IsZero() {
+			return dt.Time
+		}
+	}
+	for _, date := range []string{e.Published, e.Updated} {
+		if t, err := Date(date).Parse(); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// HasCategory checks if the item has one of the categories, ignoring case.
+func (item *Item) HasCategory(categories ...string) bool {
+	for _, category := range item.Category {
+		if containsFold(categories, strings.TrimSpace(category)) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCategory checks if the term or label of one of the entry categories
+// is one of the categories, ignoring case.
+func (e *Entry) HasCategory(categories ...string) bool {
+	for _, category := range e.Category {
+		if containsFold(categories, strings.TrimSpace(category.Term)) || containsFold(categories, strings.TrimSpace(category.Label)) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasKeyword checks if the title, description or content of the item
+// contains one of the keywords, ignoring case.
+func (item *Item) HasKeyword(keywords ...string) bool {
+	return containsAnyFold([]string{item.Title, item.Description, item.Content}, keywords)
+}
+
+// HasKeyword checks if the title, summary or content of the entry
+// contains one of the keywords, ignoring case.
+func (e *Entry) HasKeyword(keywords ...string) bool {
+	return containsAnyFold([]string{e.Title, e.Summary.Body, e.Content.Body}, keywords)
+}
+
+// DefaultItemKey returns the key of the item, see Item.Key.
+func DefaultItemKey(item *Item) string {
+	return item.Key()
+}
+
+// DefaultEntryKey returns the key of the entry, see Entry.Key.
+func DefaultEntryKey(entry *Entry) string {
+	return entry.Key()
+}
+
+// SortByDate returns a copy of the channel with the items sorted
+// newest first. Items without valid date are sorted last,
+// items with the same date keep their order.
+func (c *Channel) SortByDate() *Channel {
+	items := slices.Clone(c.Item)
+	slices.SortStableFunc(items, func(a, b Item) int {
+		return compareNewestFirst(a.Time(), b.Time())
+	})
+	return c.withItems(items)
+}
+
+// Filter returns a copy of the channel with the items for which keep returns true.
+func (c *Channel) Filter(keep func(item *Item) bool) *Channel {
+	var items []Item
+	for i := range c.Item {
+		if keep(&c.Item[i]) {
+			items = append(items, c.Item[i])
+		}
+	}
+	return c.withItems(items)
+}
+
+// Since returns a copy of the channel with the items published at or after t.
+// Items without valid date are dropped.
+func (c *Channel) Since(t time.Time) *Channel {
+	return c.Filter(func(item *Item) bool {
+		itemTime := item.Time()
+		return !itemTime.IsZero() && !itemTime.Before(t)
+	})
+}
+
+// Until returns a copy of the channel with the items published before t.
+// Items without valid date are dropped.
+func (c *Channel) Until(t time.Time) *Channel {
+	return c.Filter(func(item *Item) bool {
+		itemTime := item.Time()
+		return !itemTime.IsZero() && itemTime.Before(t)
+	})
+}
+
+// WithCategory returns a copy of the channel with the items
+// that have one of the categories, ignoring case.
+func (c *Channel) WithCategory(categories ...string) *Channel {
+	return c.Filter(func(item *Item) bool {
+		return item.HasCategory(categories...)
+	})
+}
+
+// WithKeyword returns a copy of the channel with the items
+// that contain one of the keywords, see Item.HasKeyword.
+func (c *Channel) WithKeyword(keywords ...string) *Channel {
+	return c.Filter(func(item *Item) bool {
+		return item.HasKeyword(keywords...)
+	})
+}
+
+// Dedupe returns a copy of the channel without items that have the same
+// key as a previous item. Items with an empty key are always kept.
+// If key is nil, DefaultItemKey is used. Use Item.KeyWithOptions
+// for other key strategies, for example:
+//
+//	channel.Dedupe(func(item *Item) string {
+//		return item.KeyWithOptions(KeyOptions{NormalizeURLs: true})
+//	})
+func (c *Channel) Dedupe(key func(item *Item) string) *Channel {
+	if key == nil {
+		key = DefaultItemKey
+	}
+	seen := make(map[string]bool)
+	return c.Filter(func(item *Item) bool {
+		k := key(item)
+		if k == "" {
+			return true
+		}
+		if seen[k] {
+			return false
+		}
+		seen[k] = true
+		return true
+	})
+}
+
+func (c *Channel) withItems(items []Item) *Channel {
+	channel := *c
+	channel.Item = items
+	return &channel
+}
+
+// SortByDate returns a copy of the feed with the entries sorted newest first,
+// see Entry.Time. Entries without valid date are sorted last,
+// entries with the same date keep their order.
+func (f *Feed) SortByDate() *Feed {
+	entries := slices.Clone(f.Entry)
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return compareNewestFirst(a.Time(), b.Time())
+	})
+	return f.withEntries(entries)
+}
+
+// Filter returns a copy of the feed with the entries for which keep returns true.
+func (f *Feed) Filter(keep func(entry *Entry) bool) *Feed {
+	var entries []Entry
+	for i := range f.Entry {
+		if keep(&f.Entry[i]) {
+			entries = append(entries, f.Entry[i])
+		}
+	}
+	return f.withEntries(entries)
+}
+
+// Since returns a copy of the feed with the entries published at or after t,
+// see Entry.Time. Entries without valid date are dropped.
+func (f *Feed) Since(t time.Time) *Feed {
+	return f.Filter(func(entry *Entry) bool {
+		entryTime := entry.Time()
+		return !entryTime.IsZero() && !entryTime.Before(t)
+	})
+}
+
+// Until returns a copy of the feed with the entries published before t,
+// see Entry.Time. Entries without valid date are dropped.
+func (f *Feed) Until(t time.Time) *Feed {
+	return f.Filter(func(entry *Entry) bool {
+		entryTime := entry.Time()
+		return !entryTime.IsZero() && entryTime.Before(t)
+	})
+}
+
+// WithCategory returns a copy of the feed with the entries
+// that have one of the categories, see Entry.HasCategory.
+func (f *Feed) WithCategory(categories ...string) *Feed {
+	return f.Filter(func(entry *Entry) bool {
+		return entry.HasCategory(categories...)
+	})
+}
+
+// WithKeyword returns a copy of the feed with the entries
+// that contain one of the keywords, see Entry.HasKeyword.
+func (f *Feed) WithKeyword(keywords ...string) *Feed {
+	return f.Filter(func(entry *Entry) bool {
+		return entry.HasKeyword(keywords...)
+	})
+}
+
+// Dedupe returns a copy of the feed without entries that have the same
+// key as a previous entry. Entries with an empty key are always kept.
+// If key is nil, DefaultEntryKey is used.
+func (f *Feed) Dedupe(key func(entry *Entry) string) *Feed {
+	if key == nil {
+		key = DefaultEntryKey
+	}
+	seen := make(map[string]bool)
+	return f.Filter(func(entry *Entry) bool {
+		k := key(entry)
+		if k == "" {
+			return true
+		}
+		if seen[k] {
+			return false
+		}
+		seen[k] = true
+		return true
+	})
+}
+
+func (f *Feed) withEntries(entries []Entry) *Feed {
+	feed := *f
+	feed.Entry = entries
+	return &feed
+}
+
+// compareNewestFirst compares times for sorting newest first
+// with zero times last.
+func compareNewestFirst(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return b.Compare(a)
+}
+
+func containsFold(values []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnyFold checks if one of the texts contains one of the keywords, ignoring case.
+func containsAnyFold(texts, keywords []string) bool {
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" {
+			continue
+		}
+		for _, text := range texts {
+			if strings.Contains(strings.ToLower(text), keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
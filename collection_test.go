@@ -0,0 +1,106 @@
+package rss
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCollectionRSS = `<rss version="2.0"><channel>
+	<title>Collection</title>
+	<item><title>Middle</title><guid>1</guid><pubDate>Tue, 05 Mar 2024 12:00:00 +0000</pubDate><category>Go</category></item>
+	<item><title>Undated</title><link>https://example.com/undated</link></item>
+	<item><title>Newest</title><guid>2</guid><pubDate>Thu, 07 Mar 2024 12:00:00 +0000</pubDate><description>About Golang</description></item>
+	<item><title>Middle again</title><guid>1</guid><pubDate>Tue, 05 Mar 2024 12:00:00 +0000</pubDate></item>
+	<item><title>Oldest</title><guid>3</guid><pubDate>Fri, 01 Mar 2024 12:00:00 +0000</pubDate><category> news </category></item>
+</channel></rss>`
+
+func itemTitles(channel *Channel) string {
+	var titles []string
+	for _, item := range channel.Item {
+		titles = append(titles, item.Title)
+	}
+	return strings.Join(titles, ", ")
+}
+
+func entryTitles(feed *Feed) string {
+	var titles []string
+	for _, entry := range feed.Entry {
+		titles = append(titles, entry.Title)
+	}
+	return strings.Join(titles, ", ")
+}
+
+// TestChannelCollection tests the collection helpers of channels
+func TestChannelCollection(t *testing.T) {
+	channel, err := ParseRegular(context.Background(), strings.NewReader(testCollectionRSS))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	original := itemTitles(channel)
+
+	testCases := []struct {
+		name     string
+		result   *Channel
+		expected string
+	}{
+		{"SortByDate", channel.SortByDate(), "Newest, Middle, Middle again, Oldest, Undated"},
+		{"Since", channel.Since(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)), "Middle, Newest, Middle again"},
+		{"Until", channel.Until(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)), "Oldest"},
+		{"WithCategory", channel.WithCategory("go", "NEWS"), "Middle, Oldest"},
+		{"WithKeyword", channel.WithKeyword("golang", "undated"), "Undated, Newest"},
+		{"Dedupe", channel.Dedupe(nil), "Middle, Undated, Newest, Oldest"},
+		{"Dedupe by title", channel.Dedupe(func(item *Item) string { return strings.Fields(item.Title)[0] }), "Middle, Undated, Newest, Oldest"},
+		{"Filter", channel.Filter(func(item *Item) bool { return item.GUID == "" }), "Undated"},
+		{"Chained", channel.Since(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).Dedupe(nil).SortByDate(), "Newest, Middle, Oldest"},
+	}
+	for _, tc := range testCases {
+		if titles := itemTitles(tc.result); titles != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, titles)
+		}
+		if tc.result.Title != channel.Title {
+			t.Errorf("%s: expected channel title to be kept", tc.name)
+		}
+	}
+	if titles := itemTitles(channel); titles != original {
+		t.Errorf("Expected original channel to be unchanged, got %q", titles)
+	}
+}
+
+// TestFeedCollection tests the collection helpers of Atom feeds
+func TestFeedCollection(t *testing.T) {
+	atomData := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:feed</id>
+	<title>Collection</title>
+	<updated>2024-03-07T12:00:00Z</updated>
+	<entry><id>urn:1</id><title>Updated only</title><updated>2024-03-05T12:00:00Z</updated><category term="go" label="Go"/></entry>
+	<entry><id>urn:2</id><title>Published</title><updated>2024-03-08T12:00:00Z</updated><published>2024-03-01T12:00:00Z</published><summary>Release notes</summary></entry>
+	<entry><id>urn:1</id><title>Duplicate</title><updated>2024-03-07T12:00:00Z</updated><category term="tag:news" label="News"/></entry>
+</feed>`
+	feed, err := ParseAtom(context.Background(), strings.NewReader(atomData))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		result   *Feed
+		expected string
+	}{
+		{"SortByDate", feed.SortByDate(), "Duplicate, Updated only, Published"},
+		{"Since", feed.Since(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)), "Updated only, Duplicate"},
+		{"Until", feed.Until(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)), "Published"},
+		{"WithCategory", feed.WithCategory("news"), "Duplicate"},
+		{"WithKeyword", feed.WithKeyword("release"), "Published"},
+		{"Dedupe", feed.Dedupe(nil), "Updated only, Published"},
+	}
+	for _, tc := range testCases {
+		if titles := entryTitles(tc.result); titles != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, titles)
+		}
+	}
+	if len(feed.Entry[0].Category) != 1 || feed.Entry[0].Category[0].Term != "go" {
+		t.Errorf("Unexpected categories %+v", feed.Entry[0].Category)
+	}
+}
@@ -0,0 +1,319 @@
+package rss
+
+import (
+	"html"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonFeedVersion is the version written by the JSON Feed conversions.
+const jsonFeedVersion = jsonFeedVersionPrefix + "1.1"
+
+// ToAtom converts the channel to an Atom feed.
+// Dates are converted to RFC 3339, item descriptions become entry summaries
+// and enclosures become links with rel="enclosure".
+// Elements without an Atom equivalent like comments are dropped.
+func (c *Channel) ToAtom() *Feed {
+	feed := &Feed{
+		ID:      c.Link,
+		Title:   c.Title,
+		Updated: atomDate(c.LastBuildDate),
+		XMLBase: c.XMLBase,
+	}
+	if c.Link != "" {
+		feed.Link = []Link{{Href: c.Link, Rel: "alternate"}}
+	}
+	var latest time.Time
+	for i := range c.Item {
+		entry := c.Item[i].toAtom()
+		if updated, err := time.Parse(time.RFC3339, entry.Updated); err == nil && updated.After(latest) {
+			latest = updated
+		}
+		feed.Entry = append(feed.Entry, entry)
+	}
+	if feed.Updated == "" && !latest.IsZero() {
+		// Atom requires an updated date, use the one of the latest entry
+		feed.Updated = latest.Format(time.RFC3339)
+	}
+	return feed
+}
+
+func (item *Item) toAtom() Entry {
+	entry := Entry{
+		ID:        item.GUID,
+		Title:     item.Title,
+		Updated:   atomDate(item.PubDate),
+		Published: atomDate(item.PubDate),
+		XMLBase:   item.XMLBase,
+	}
+	if entry.ID == "" {
+		entry.ID = item.Link
+	}
+	if item.Author != "" {
+		entry.Author = []Person{parseRSSAuthor(item.Author)}
+	}
+	for _, category := range item.Category {
+		entry.Category = append(entry.Category, Category{Term: category})
+	}
+	if item.Link != "" {
+		entry.Link = append(entry.Link, Link{Href: item.Link, Rel: "alternate"})
+	}
+	for _, enclosure := range item.Enclosure {
+		entry.Link = append(entry.Link, Link{
+			Href:   enclosure.URL,
+			Rel:    "enclosure",
+			Type:   enclosure.Type,
+			Length: enclosure.Length,
+		})
+	}
+	if item.Source != nil {
+		entry.Source = &Source{Title: item.Source.Title}
+		if item.Source.URL != "" {
+			entry.Source.Link = []Link{{Href: item.Source.URL, Rel: "self"}}
+		}
+	}
+	if item.Description != "" {
+		entry.Summary = Content{Type: "html", Body: item.Description}
+	}
+	if item.Content != "" {
+		entry.Content = Content{Type: "html", Body: item.Content}
+	}
+	return entry
+}
+
+// ToRegular converts the Atom feed to an RSS channel.
+// Dates are converted to RFC 1123 with numeric zone as used by RSS 2.0,
+// entry summaries become item descriptions and links with
+// rel="enclosure" become enclosures.
+func (f *Feed) ToRegular() *Channel {
+	channel := &Channel{
+		Title:         f.Title,
+		Link:          f.URL(),
+		Description:   f.Title,
+		LastBuildDate: rssDate(f.Updated),
+	}
+	for i := range f.Entry {
+		channel.Item = append(channel.Item, f.Entry[i].toRegular())
+	}
+	return channel
+}
+
+func (e *Entry) toRegular() Item {
+	published := e.Published
+	if published == "" {
+		published = e.Updated
+	}
+	item := Item{
+		Title:       e.Title,
+		Link:        e.URL(),
+		GUID:        e.ID,
+		PubDate:     rssDate(published),
+		Description: e.Summary.HTML(),
+		Content:     e.Content.HTML(),
+	}
+	if len(e.Author) > 0 {
+		item.Author = formatRSSAuthor(e.Author[0])
+	}
+	if e.Source != nil {
+		item.Source = &ItemSource{URL: selfLink(e.Source.Link), Title: e.Source.Title}
+		if item.Source.URL == "" {
+			item.Source.URL = alternateLink(e.Source.Link)
+		}
+	}
+	for _, category := range e.Category {
+		item.Category = append(item.Category, category.Term)
+	}
+	for _, link := range e.Link {
+		if link.Rel == "enclosure" {
+			item.Enclosure = append(item.Enclosure, ItemEnclosure{URL: link.Href, Type: link.Type, Length: link.Length})
+		}
+	}
+	return item
+}
+
+// ToJSONFeed converts the channel to a JSON Feed.
+// Item content is used as content_html, falling back to the description
+// which is then also used as plain text summary.
+func (c *Channel) ToJSONFeed() *JSONFeed {
+	feed := &JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       c.Title,
+		HomePageURL: c.Link,
+		Description: c.Description,
+		Language:    c.Language,
+		Items:       []JSONFeedItem{},
+	}
+	for _, item := range c.Item {
+		jsonItem := JSONFeedItem{
+			ID:            item.GUID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			DatePublished: atomDate(item.PubDate),
+			Tags:          item.Category,
+		}
+		if jsonItem.ID == "" {
+			jsonItem.ID = item.Link
+		}
+		if jsonItem.ContentHTML == "" {
+			jsonItem.ContentHTML = item.Description
+		} else if item.Description != "" {
+			jsonItem.Summary = HTMLToText(item.Description)
+		}
+		if item.Author != "" {
+			person := parseRSSAuthor(item.Author)
+			jsonItem.Authors = []JSONFeedAuthor{{Name: person.Name}}
+			if person.Name == "" {
+				jsonItem.Authors[0].Name = person.Email
+			}
+		}
+		for _, enclosure := range item.Enclosure {
+			size, _ := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+			jsonItem.Attachments = append(jsonItem.Attachments, JSONFeedAttachment{
+				URL:         enclosure.URL,
+				MIMEType:    enclosure.Type,
+				SizeInBytes: size,
+			})
+		}
+		feed.Items = append(feed.Items, jsonItem)
+	}
+	return feed
+}
+
+// ToJSONFeed converts the Atom feed to a JSON Feed.
+func (f *Feed) ToJSONFeed() *JSONFeed {
+	return f.ToRegular().ToJSONFeed()
+}
+
+// ToRegular converts the JSON Feed to an RSS channel.
+// The summary of items becomes the description and content_html the content,
+// content_text is used HTML escaped if an item has no content_html.
+func (j *JSONFeed) ToRegular() *Channel {
+	channel := &Channel{
+		Title:       j.Title,
+		Link:        j.HomePageURL,
+		Description: j.Description,
+		Language:    j.Language,
+	}
+	if channel.Description == "" {
+		channel.Description = j.Title
+	}
+	for _, jsonItem := range j.Items {
+		item := Item{
+			Title:       jsonItem.Title,
+			Link:        jsonItem.URL,
+			GUID:        jsonItem.ID,
+			PubDate:     rssDate(jsonItem.DatePublished),
+			Description: html.EscapeString(jsonItem.Summary),
+			Content:     jsonItem.ContentHTML,
+			Category:    jsonItem.Tags,
+		}
+		if item.Content == "" && jsonItem.ContentText != "" {
+			item.Content = html.EscapeString(jsonItem.ContentText)
+		}
+		if item.Description == "" {
+			item.Description, item.Content = item.Content, ""
+		}
+		if len(jsonItem.Authors) > 0 {
+			item.Author = jsonItem.Authors[0].Name
+		}
+		for _, attachment := range jsonItem.Attachments {
+			enclosure := ItemEnclosure{URL: attachment.URL, Type: attachment.MIMEType}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			item.Enclosure = append(item.Enclosure, enclosure)
+		}
+		channel.Item = append(channel.Item, item)
+	}
+	return channel
+}
+
+// ToAtom converts the JSON Feed to an Atom feed.
+func (j *JSONFeed) ToAtom() *Feed {
+	return j.ToRegular().ToAtom()
+}
+
+// ToRegular returns the parsed feed as RSS channel, converted if necessary.
+func (p *ParsedFeed) ToRegular() *Channel {
+	switch p.Format {
+	case FormatAtom:
+		return p.Feed.ToRegular()
+	case FormatJSON:
+		return p.JSONFeed.ToRegular()
+	}
+	return p.Channel
+}
+
+// ToAtom returns the parsed feed as Atom feed, converted if necessary.
+func (p *ParsedFeed) ToAtom() *Feed {
+	switch p.Format {
+	case FormatRSS:
+		return p.Channel.ToAtom()
+	case FormatJSON:
+		return p.JSONFeed.ToAtom()
+	}
+	return p.Feed
+}
+
+// ToJSONFeed returns the parsed feed as JSON Feed, converted if necessary.
+func (p *ParsedFeed) ToJSONFeed() *JSONFeed {
+	switch p.Format {
+	case FormatRSS:
+		return p.Channel.ToJSONFeed()
+	case FormatAtom:
+		return p.Feed.ToJSONFeed()
+	}
+	return p.JSONFeed
+}
+
+// atomDate returns the date in RFC 3339 format as used by Atom and JSON Feed,
+// or the date as is if it can't be parsed.
+func atomDate(d Date) string {
+	t, err := d.Parse()
+	if err != nil {
+		return strings.TrimSpace(string(d))
+	}
+	return t.Format(time.RFC3339)
+}
+
+// rssDate returns an RFC 3339 date in the RFC 1123 format with numeric
+// zone as used by RSS 2.0, or the date as is if it can't be parsed.
+func rssDate(date string) Date {
+	date = strings.TrimSpace(date)
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return Date(date)
+	}
+	return Date(t.Format(time.RFC1123Z))
+}
+
+// parseRSSAuthor parses the author of an RSS item which should be
+// an email address optionally followed by the name in parentheses,
+// but is often just a name.
+func parseRSSAuthor(author string) Person {
+	author = strings.TrimSpace(author)
+	if address, err := mail.ParseAddress(author); err == nil {
+		return Person{Name: address.Name, Email: address.Address}
+	}
+	if email, name, found := strings.Cut(author, " ("); found && strings.HasSuffix(name, ")") {
+		if _, err := mail.ParseAddress(email); err == nil {
+			return Person{Name: strings.TrimSuffix(name, ")"), Email: email}
+		}
+	}
+	return Person{Name: author}
+}
+
+// formatRSSAuthor formats a person in the "email (name)" format of RSS 2.0,
+// or returns just the name if the person has no email address.
+func formatRSSAuthor(person Person) string {
+	switch {
+	case person.Email == "":
+		return person.Name
+	case person.Name == "":
+		return person.Email
+	}
+	return person.Email + " (" + person.Name + ")"
+}
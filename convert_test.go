@@ -0,0 +1,194 @@
+package rss
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestChannelToAtom tests the conversion of RSS items to Atom entries
+func TestChannelToAtom(t *testing.T) {
+	channel := &Channel{
+		Title: "Channel",
+		Link:  "https://example.com/",
+		Item: []Item{{
+			Title:       "Item",
+			Link:        "https://example.com/item",
+			GUID:        "urn:item",
+			PubDate:     "Mon, 01 Jan 2024 12:00:00 +0100",
+			Author:      "jane@example.com (Jane Doe)",
+			Description: "<p>Summary</p>",
+			Enclosure:   []ItemEnclosure{{URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: "1234"}},
+		}},
+	}
+
+	feed := channel.ToAtom()
+	if feed.Title != "Channel" || feed.URL() != "https://example.com/" || feed.Updated != "2024-01-01T12:00:00+01:00" {
+		t.Errorf("Unexpected feed: %+v", feed)
+	}
+	entry := feed.Entry[0]
+	if entry.ID != "urn:item" || entry.URL() != "https://example.com/item" || entry.Updated != "2024-01-01T12:00:00+01:00" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if len(entry.Author) != 1 || entry.Author[0].Name != "Jane Doe" || entry.Author[0].Email != "jane@example.com" {
+		t.Errorf("Unexpected author: %+v", entry.Author)
+	}
+	if entry.Summary.Type != "html" || entry.Summary.Body != "<p>Summary</p>" {
+		t.Errorf("Unexpected summary: %+v", entry.Summary)
+	}
+
+	item := feed.ToRegular().Item[0]
+	if item.PubDate != "Mon, 01 Jan 2024 12:00:00 +0100" || item.Author != channel.Item[0].Author {
+		t.Errorf("Unexpected item after round trip: %+v", item)
+	}
+	if len(item.Enclosure) != 1 || item.Enclosure[0] != channel.Item[0].Enclosure[0] {
+		t.Errorf("Unexpected enclosures after round trip: %+v", item.Enclosure)
+	}
+}
+
+// TestJSONFeedConversion tests the conversion between RSS and JSON Feed
+func TestJSONFeedConversion(t *testing.T) {
+	file, err := os.Open(filepath.Join(testDataDir, "podcast.rss"))
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+	channel, err := ParseRegular(context.Background(), file)
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+
+	jsonFeed := channel.ToJSONFeed()
+	if jsonFeed.Version != "https://jsonfeed.org/version/1.1" || len(jsonFeed.Items) != len(channel.Item) {
+		t.Fatalf("Unexpected JSON Feed: %s with %d items", jsonFeed.Version, len(jsonFeed.Items))
+	}
+	first := jsonFeed.Items[0]
+	if first.DatePublished != "2015-05-30T11:11:00Z" || len(first.Attachments) == 0 || first.Attachments[0].SizeInBytes != 63963136 {
+		t.Errorf("Unexpected first item: %+v", first)
+	}
+
+	converted := jsonFeed.ToRegular()
+	if converted.Title != channel.Title || len(converted.Item) != len(channel.Item) {
+		t.Fatalf("Unexpected channel after round trip: %q with %d items", converted.Title, len(converted.Item))
+	}
+	if converted.Item[0].Description != channel.Item[0].Description {
+		t.Errorf("Expected description to survive round trip")
+	}
+}
+
+// TestRSSAuthor tests the conversion of RSS authors to Atom persons and back
+func TestRSSAuthor(t *testing.T) {
+	testCases := []struct {
+		author string
+		person Person
+		format string
+	}{
+		{"jane@example.com (Jane Doe)", Person{Name: "Jane Doe", Email: "jane@example.com"}, "jane@example.com (Jane Doe)"},
+		{"Jane Doe <jane@example.com>", Person{Name: "Jane Doe", Email: "jane@example.com"}, "jane@example.com (Jane Doe)"},
+		{" jane@example.com ", Person{Email: "jane@example.com"}, "jane@example.com"},
+		{"Jane Doe", Person{Name: "Jane Doe"}, "Jane Doe"},
+		{"Jane (Editor)", Person{Name: "Jane (Editor)"}, "Jane (Editor)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.author, func(t *testing.T) {
+			person := parseRSSAuthor(tc.author)
+			if person != tc.person {
+				t.Errorf("Expected %+v, got %+v", tc.person, person)
+			}
+			if formatted := formatRSSAuthor(person); formatted != tc.format {
+				t.Errorf("Expected %q, got %q", tc.format, formatted)
+			}
+		})
+	}
+}
+
+// TestConversionDates tests the conversion between RSS and Atom dates
+func TestConversionDates(t *testing.T) {
+	testCases := []struct {
+		rss  Date
+		atom string
+	}{
+		{"Mon, 01 Jan 2024 12:00:00 +0100", "2024-01-01T12:00:00+01:00"},
+		{"Mon, 01 Jan 2024 12:00:00 +0000", "2024-01-01T12:00:00Z"},
+		{"yesterday", "yesterday"},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		if atom := atomDate(tc.rss); atom != tc.atom {
+			t.Errorf("atomDate(%q): expected %q, got %q", tc.rss, tc.atom, atom)
+		}
+		if rss := rssDate(tc.atom); rss != tc.rss {
+			t.Errorf("rssDate(%q): expected %q, got %q", tc.atom, tc.rss, rss)
+		}
+	}
+}
+
+// TestFeedToRegular tests the conversion of Atom entries to RSS items
+func TestFeedToRegular(t *testing.T) {
+	feed := &Feed{
+		Title:   "Feed",
+		Updated: "2024-01-02T12:00:00Z",
+		Link:    []Link{{Href: "https://example.com/feed.atom", Rel: "self"}, {Href: "https://example.com/"}},
+		Entry: []Entry{{
+			ID:       "urn:entry",
+			Title:    "Entry",
+			Updated:  "2024-01-02T12:00:00Z",
+			Author:   []Person{{Name: "Jane Doe", Email: "jane@example.com"}},
+			Category: []Category{{Term: "go"}},
+			Link: []Link{
+				{Href: "https://example.com/entry"},
+				{Href: "https://example.com/a.mp3", Rel: "enclosure", Type: "audio/mpeg", Length: "1234"},
+			},
+			Summary: Content{Type: "text", Body: "Fish & Chips"},
+			Source:  &Source{Title: "Origin", Link: []Link{{Href: "https://origin.example.com/"}}},
+		}},
+	}
+
+	channel := feed.ToRegular()
+	if channel.Title != "Feed" || channel.Link != "https://example.com/" || channel.Description != "Feed" || channel.LastBuildDate != "Tue, 02 Jan 2024 12:00:00 +0000" {
+		t.Errorf("Unexpected channel: %+v", channel)
+	}
+	item := channel.Item[0]
+	if item.GUID != "urn:entry" || item.Link != "https://example.com/entry" || item.PubDate != "Tue, 02 Jan 2024 12:00:00 +0000" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+	if item.Author != "jane@example.com (Jane Doe)" || len(item.Category) != 1 || item.Category[0] != "go" {
+		t.Errorf("Unexpected author or categories: %q, %q", item.Author, item.Category)
+	}
+	if item.Description != "Fish &amp; Chips" {
+		t.Errorf("Expected escaped text summary, got %q", item.Description)
+	}
+	if len(item.Enclosure) != 1 || item.Enclosure[0] != (ItemEnclosure{URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: "1234"}) {
+		t.Errorf("Unexpected enclosures: %+v", item.Enclosure)
+	}
+	if item.Source == nil || item.Source.Title != "Origin" || item.Source.URL != "https://origin.example.com/" {
+		t.Errorf("Unexpected source: %+v", item.Source)
+	}
+}
+
+// TestParsedFeedConversion tests that ParsedFeed converts to every format
+func TestParsedFeedConversion(t *testing.T) {
+	channel := &Channel{Title: "Channel", Item: []Item{{Title: "Item", GUID: "urn:item"}}}
+	testCases := []*ParsedFeed{
+		{Format: FormatRSS, Channel: channel},
+		{Format: FormatAtom, Feed: channel.ToAtom()},
+		{Format: FormatJSON, JSONFeed: channel.ToJSONFeed()},
+	}
+	for _, parsed := range testCases {
+		t.Run(string(parsed.Format), func(t *testing.T) {
+			if c := parsed.ToRegular(); c.Title != "Channel" || len(c.Item) != 1 || c.Item[0].GUID != "urn:item" {
+				t.Errorf("Unexpected channel: %+v", c)
+			}
+			if f := parsed.ToAtom(); f.Title != "Channel" || len(f.Entry) != 1 || f.Entry[0].ID != "urn:item" {
+				t.Errorf("Unexpected feed: %+v", f)
+			}
+			if j := parsed.ToJSONFeed(); j.Title != "Channel" || len(j.Items) != 1 || j.Items[0].ID != "urn:item" {
+				t.Errorf("Unexpected JSON Feed: %+v", j)
+			}
+		})
+	}
+	if parsed := testCases[0]; parsed.ToRegular() != channel {
+		t.Error("Expected the parsed channel without conversion")
+	}
+}
@@ -0,0 +1,163 @@
+package rss
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the layouts tried by Date.Parse in this order.
+// Besides the RFC 822 dates of RSS 2.0 and the RFC 3339 dates of Atom
+// they cover common deviations found in real-world feeds.
+var dateLayouts = []string{
+	wordpressDateFormat,
+	time.RFC822,  // RSS 2.0 spec
+	time.RFC3339, // Atom
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC850,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"Mon, 02 Jan 06 15:04:05 -0700",
+	"Mon, 02 Jan 06 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05",
+	"Monday, 02-Jan-06 15:04:05 -0700",
+	time.ANSIC,
+	time.UnixDate,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// zoneOffsets are the offsets in seconds of the North American zone
+// abbreviations of RFC 822. The time package only knows the offset of
+// an abbreviation if it is used by the location and parses others as UTC.
+var zoneOffsets = map[string]int{
+	"EST": -5 * 3600,
+	"EDT": -4 * 3600,
+	"CST": -6 * 3600,
+	"CDT": -5 * 3600,
+	"MST": -7 * 3600,
+	"MDT": -6 * 3600,
+	"PST": -8 * 3600,
+	"PDT": -7 * 3600,
+}
+
+// ParseInLocation parses the date like Parse, but interprets dates
+// without time zone in the given location instead of UTC.
+func (d Date) ParseInLocation(loc *time.Location) (time.Time, error) {
+	s := militaryZone(strings.TrimSpace(string(d)))
+	t, err := time.ParseInLocation(dateLayouts[0], s, loc)
+	for _, layout := range dateLayouts[1:] {
+		if err == nil {
+			break
+		}
+		t, err = time.ParseInLocation(layout, s, loc)
+	}
+	if err != nil {
+		return t, err
+	}
+	if name, offset := t.Zone(); zoneOffsets[name] != 0 && zoneOffsets[name] != offset {
+		zone := time.FixedZone(name, zoneOffsets[name])
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), zone)
+	}
+	return t, nil
+}
+
+// militaryZone replaces a trailing single-letter military zone of RFC 822,
+// which the time package can't parse, with its numeric offset.
+// "Z" is UTC, "A" to "M" without "J" are +1 to +12 hours
+// and "N" to "Y" are -1 to -12 hours.
+func militaryZone(s string) string {
+	n := len(s)
+	if n < 2 || s[n-2] != ' ' || s[n-1] < 'A' || s[n-1] > 'Z' || s[n-1] == 'J' {
+		return s
+	}
+	var hours int
+	switch letter := int(s[n-1]); {
+	case letter <= 'I':
+		hours = letter - 'A' + 1
+	case letter <= 'M':
+		hours = letter - 'A'
+	case letter <= 'Y':
+		hours = 'M' - letter
+	}
+	sign := '+'
+	if hours < 0 {
+		sign, hours = '-', -hours
+	}
+	return fmt.Sprintf("%s%c%02d00", s[:n-1], sign, hours)
+}
+
+// DateTime is a date of a feed resolved to a time.Time during parsing.
+// The parsing functions set it next to the raw date string,
+// for example Item.PubTime for Item.PubDate.
+type DateTime struct {
+	// Time is the parsed date. It is ParseOptions.DefaultDate,
+	// usually the zero time, if the date is missing or invalid.
+	Time time.Time
+
+	// Raw is the trimmed date as found in the feed
+	Raw string
+
+	// Invalid is true if the date is present but could not be parsed
+	Invalid bool
+}
+
+// IsZero checks if the time is the zero time,
+// which is the case for missing or invalid dates without default.
+func (d DateTime) IsZero() bool {
+	return d.Time.IsZero()
+}
+
+// Format formats the time using the specified format string,
+// or returns an empty string if the time is zero.
+func (d DateTime) Format(format string) string {
+	if d.Time.IsZero() {
+		return ""
+	}
+	return d.Time.Format(format)
+}
+
+// newDateTime parses the raw date, using the location and default date of the options.
+func newDateTime(raw string, options ParseOptions) DateTime {
+	dt := DateTime{Time: options.DefaultDate, Raw: strings.TrimSpace(raw)}
+	if dt.Raw == "" {
+		return dt
+	}
+	loc := options.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t, err := Date(dt.Raw).ParseInLocation(loc)
+	if err != nil {
+		dt.Invalid = true
+		return dt
+	}
+	dt.Time = t
+	return dt
+}
+
+// resolveDates sets the parsed times of the channel and its items.
+func (c *Channel) resolveDates(options ParseOptions) {
+	c.LastBuildTime = newDateTime(string(c.LastBuildDate), options)
+	for i := range c.Item {
+		c.Item[i].PubTime = newDateTime(string(c.Item[i].PubDate), options)
+	}
+}
+
+// resolveDates sets the parsed times of the feed and its entries.
+func (f *Feed) resolveDates(options ParseOptions) {
+	f.UpdatedTime = newDateTime(f.Updated, options)
+	for i := range f.Entry {
+		f.Entry[i].UpdatedTime = newDateTime(f.Entry[i].Updated, options)
+		f.Entry[i].PublishedTime = newDateTime(f.Entry[i].Published, options)
+	}
+}
@@ -0,0 +1,205 @@
+package rss
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDateParseFormats tests the date formats found in real-world feeds
+func TestDateParseFormats(t *testing.T) {
+	expected := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	testCases := []string{
+		"Tue, 05 Mar 2024 14:30:00 +0000",
+		"Tue, 05 Mar 2024 14:30:00 GMT",
+		"Tue, 5 Mar 2024 14:30:00 +0000",
+		"Tue, 5 Mar 2024 14:30 GMT",
+		"05 Mar 24 14:30 UTC",
+		"5 Mar 2024 14:30:00 +0000",
+		"Tuesday, 05-Mar-24 14:30:00 UTC",
+		"2024-03-05T14:30:00Z",
+		"2024-03-05T16:30:00+02:00",
+		"2024-03-05T14:30:00+0000",
+		"2024-03-05T14:30:00",
+		"2024-03-05 14:30:00",
+		"  Tue, 05 Mar 2024 14:30:00 +0000\n",
+	}
+	for _, tc := range testCases {
+		parsed, err := Date(tc).Parse()
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tc, err)
+			continue
+		}
+		if !parsed.Equal(expected) {
+			t.Errorf("Expected %q to be %v, got %v", tc, expected, parsed)
+		}
+	}
+}
+
+// TestDateParseZoneAbbreviations tests the offsets of the RFC 822 zone names
+func TestDateParseZoneAbbreviations(t *testing.T) {
+	testCases := []struct {
+		date   string
+		offset int
+	}{
+		{"Tue, 05 Mar 2024 14:30:00 EST", -5},
+		{"Tue, 05 Mar 2024 14:30:00 EDT", -4},
+		{"Tue, 05 Mar 2024 14:30:00 CST", -6},
+		{"Tue, 05 Mar 2024 14:30:00 CDT", -5},
+		{"Tue, 05 Mar 2024 14:30:00 MST", -7},
+		{"Tue, 05 Mar 2024 14:30:00 MDT", -6},
+		{"Tue, 05 Mar 2024 14:30:00 PST", -8},
+		{"Tue, 05 Mar 2024 14:30:00 PDT", -7},
+		{"05 Mar 24 14:30 PST", -8},
+		{"2024-03-05 14:30:00 EST", -5},
+		{"Tue, 05 Mar 2024 14:30:00 GMT", 0},
+		{"Tue, 05 Mar 2024 14:30:00 Z", 0},
+		{"Tue, 05 Mar 2024 14:30:00 A", 1},
+		{"Tue, 05 Mar 2024 14:30:00 I", 9},
+		{"Tue, 05 Mar 2024 14:30:00 K", 10},
+		{"05 Mar 24 14:30 M", 12},
+		{"Tue, 05 Mar 2024 14:30:00 N", -1},
+		{"Tue, 05 Mar 2024 14:30:00 Y", -12},
+	}
+	for _, tc := range testCases {
+		expected := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC).Add(-time.Duration(tc.offset) * time.Hour)
+		parsed, err := Date(tc.date).Parse()
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tc.date, err)
+			continue
+		}
+		if !parsed.Equal(expected) {
+			t.Errorf("Expected %q to be %v, got %v", tc.date, expected, parsed.UTC())
+		}
+	}
+
+	// The offset of the abbreviation applies regardless of the location
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+	parsed, err := Date("Tue, 05 Mar 2024 14:30:00 PST").ParseInLocation(loc)
+	if err != nil {
+		t.Fatalf("ParseInLocation failed: %v", err)
+	}
+	if expected := time.Date(2024, 3, 5, 22, 30, 0, 0, time.UTC); !parsed.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, parsed.UTC())
+	}
+
+	// J is not a zone
+	if _, err := Date("Tue, 05 Mar 2024 14:30:00 J").Parse(); err == nil {
+		t.Error("Expected error for zone J")
+	}
+}
+
+// TestDateParseInLocation tests the location of dates without time zone
+func TestDateParseInLocation(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	parsed, err := Date("2024-03-05 14:30:00").ParseInLocation(loc)
+	if err != nil {
+		t.Fatalf("ParseInLocation failed: %v", err)
+	}
+	if expected := time.Date(2024, 3, 5, 13, 30, 0, 0, time.UTC); !parsed.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, parsed)
+	}
+
+	// Dates with time zone are not affected by the location
+	parsed, err = Date("2024-03-05T14:30:00Z").ParseInLocation(loc)
+	if err != nil {
+		t.Fatalf("ParseInLocation failed: %v", err)
+	}
+	if expected := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC); !parsed.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, parsed)
+	}
+}
+
+const testDateTimeRSS = `<rss version="2.0"><channel>
+	<title>Dates</title>
+	<lastBuildDate>Tue, 05 Mar 2024 14:30:00 GMT</lastBuildDate>
+	<item><title>Valid</title><pubDate> Tue, 05 Mar 2024 14:30:00 +0000 </pubDate></item>
+	<item><title>Zoneless</title><pubDate>2024-03-05 14:30:00</pubDate></item>
+	<item><title>Invalid</title><pubDate>yesterday</pubDate></item>
+	<item><title>Missing</title></item>
+	<item><title>Pacific</title><pubDate>Tue, 05 Mar 2024 06:30:00 PST</pubDate></item>
+</channel></rss>`
+
+// TestParseRegularDateTime tests the parsed times of RSS channels and items
+func TestParseRegularDateTime(t *testing.T) {
+	expected := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+
+	channel, err := ParseRegular(context.Background(), strings.NewReader(testDateTimeRSS))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	if !channel.LastBuildTime.Time.Equal(expected) {
+		t.Errorf("Expected last build time %v, got %v", expected, channel.LastBuildTime.Time)
+	}
+	valid := channel.Item[0].PubTime
+	if !valid.Time.Equal(expected) || valid.Raw != "Tue, 05 Mar 2024 14:30:00 +0000" || valid.Invalid {
+		t.Errorf("Unexpected valid date %+v", valid)
+	}
+	if zoneless := channel.Item[1].PubTime; !zoneless.Time.Equal(expected) {
+		t.Errorf("Expected zoneless date in UTC, got %v", zoneless.Time)
+	}
+	invalid := channel.Item[2].PubTime
+	if !invalid.IsZero() || !invalid.Invalid || invalid.Raw != "yesterday" || invalid.Format(time.RFC3339) != "" {
+		t.Errorf("Unexpected invalid date %+v", invalid)
+	}
+	if missing := channel.Item[3].PubTime; !missing.IsZero() || missing.Invalid || missing.Raw != "" {
+		t.Errorf("Unexpected missing date %+v", missing)
+	}
+	if pacific := channel.Item[4].PubTime; !pacific.Time.Equal(expected) {
+		t.Errorf("Expected PST date at %v, got %v", expected, pacific.Time)
+	}
+
+	defaultDate := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	channel, err = ParseRegularWithOptions(context.Background(), strings.NewReader(testDateTimeRSS), ParseOptions{
+		Location:    time.FixedZone("CET", 3600),
+		DefaultDate: defaultDate,
+	})
+	if err != nil {
+		t.Fatalf("ParseRegularWithOptions failed: %v", err)
+	}
+	if zoneless := channel.Item[1].PubTime; !zoneless.Time.Equal(expected.Add(-time.Hour)) {
+		t.Errorf("Expected zoneless date in location, got %v", zoneless.Time)
+	}
+	if invalid := channel.Item[2].PubTime; !invalid.Time.Equal(defaultDate) || !invalid.Invalid {
+		t.Errorf("Expected default for invalid date, got %+v", invalid)
+	}
+	if missing := channel.Item[3].PubTime; !missing.Time.Equal(defaultDate) || missing.Invalid {
+		t.Errorf("Expected default for missing date, got %+v", missing)
+	}
+	if pacific := channel.Item[4].PubTime; !pacific.Time.Equal(expected) {
+		t.Errorf("Expected PST date independent of location, got %v", pacific.Time)
+	}
+}
+
+// TestParseAtomDateTime tests the parsed times of Atom feeds and entries
+func TestParseAtomDateTime(t *testing.T) {
+	atomData := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:feed</id>
+	<title>Dates</title>
+	<updated>2024-03-05T14:30:00Z</updated>
+	<entry>
+		<id>urn:1</id>
+		<title>Entry</title>
+		<updated>2024-03-06T10:00:00+01:00</updated>
+		<published>2024-03-05T14:30:00Z</published>
+	</entry>
+</feed>`
+	feed, err := ParseAtom(context.Background(), strings.NewReader(atomData))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	if expected := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC); !feed.UpdatedTime.Time.Equal(expected) {
+		t.Errorf("Expected feed updated %v, got %v", expected, feed.UpdatedTime.Time)
+	}
+	entry := feed.Entry[0]
+	if expected := time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC); !entry.UpdatedTime.Time.Equal(expected) {
+		t.Errorf("Expected entry updated %v, got %v", expected, entry.UpdatedTime.Time)
+	}
+	if entry.PublishedTime.Format("2006-01-02") != "2024-03-05" {
+		t.Errorf("Unexpected entry published %v", entry.PublishedTime.Time)
+	}
+}
@@ -0,0 +1,259 @@
+package rss
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ChangedField names a field of an item or entry compared by DiffRegular and DiffAtom.
+type ChangedField string
+
+const (
+	// FieldTitle is the title
+	FieldTitle ChangedField = "title"
+
+	// FieldContent is the description, content and full text of an item,
+	// or the summary and content of an entry
+	FieldContent ChangedField = "content"
+
+	// FieldEnclosure is the URL, type and length of the enclosures of an item,
+	// or the enclosure links of an entry
+	FieldEnclosure ChangedField = "enclosure"
+
+	// FieldDate is the publication date of an item,
+	// or the updated and published dates of an entry
+	FieldDate ChangedField = "date"
+)
+
+// ItemChange is an item that was modified between two parses of a channel.
+type ItemChange struct {
+	// Old is the item of the old channel
+	Old *Item
+
+	// New is the item of the new channel
+	New *Item
+
+	// Fields are the changed fields in the order title, content, enclosure, date
+	Fields []ChangedField
+}
+
+// Has reports if the field was changed.
+func (c *ItemChange) Has(field ChangedField) bool {
+	return slices.Contains(c.Fields, field)
+}
+
+// ChannelDiff is the result of DiffRegular.
+type ChannelDiff struct {
+	// Added are the items of the new channel without a match in the old channel,
+	// in the order of the new channel
+	Added []Item
+
+	// Removed are the items of the old channel without a match in the new channel,
+	// in the order of the old channel
+	Removed []Item
+
+	// Modified are the matching items with changed fields,
+	// in the order of the new channel
+	Modified []ItemChange
+}
+
+// IsEmpty reports if the channels have no differences.
+func (d *ChannelDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// DiffRegular compares two parses of the same channel and reports added,
+// removed and modified items. Items are matched by key, nil means
+// DefaultItemKey. Items with an empty key never match, and only the first
+// item of a repeated key is compared. Whitespace differences in titles
+// and content and differently formatted equal dates are not reported.
+//
+// Note that the default key of an item without GUID and link is a hash
+// of its title, date and enclosure, so such an item shows up as removed
+// and added instead of modified when those change.
+func DiffRegular(oldChannel, newChannel *Channel, key func(item *Item) string) *ChannelDiff {
+	if key == nil {
+		key = DefaultItemKey
+	}
+	oldItems := make(map[string]*Item)
+	for i := range oldChannel.Item {
+		if k := key(&oldChannel.Item[i]); k != "" && oldItems[k] == nil {
+			oldItems[k] = &oldChannel.Item[i]
+		}
+	}
+	diff := &ChannelDiff{}
+	matched := make(map[string]bool)
+	for i := range newChannel.Item {
+		newItem := &newChannel.Item[i]
+		k := key(newItem)
+		oldItem := oldItems[k]
+		switch {
+		case oldItem == nil:
+			diff.Added = append(diff.Added, *newItem)
+		case !matched[k]:
+			matched[k] = true
+			if fields := itemChanges(oldItem, newItem); len(fields) > 0 {
+				diff.Modified = append(diff.Modified, ItemChange{Old: oldItem, New: newItem, Fields: fields})
+			}
+		}
+	}
+	for i := range oldChannel.Item {
+		if k := key(&oldChannel.Item[i]); k == "" || !matched[k] {
+			diff.Removed = append(diff.Removed, oldChannel.Item[i])
+		}
+	}
+	return diff
+}
+
+// itemChanges returns the fields that differ between the items.
+func itemChanges(a, b *Item) []ChangedField {
+	var fields []ChangedField
+	if !sameText(a.Title, b.Title) {
+		fields = append(fields, FieldTitle)
+	}
+	if !sameText(a.Description, b.Description) || !sameText(a.Content, b.Content) || !sameText(a.FullText, b.FullText) {
+		fields = append(fields, FieldContent)
+	}
+	if !slices.EqualFunc(a.Enclosure, b.Enclosure, func(x, y ItemEnclosure) bool {
+		return sameText(x.URL, y.URL) && sameText(x.Type, y.Type) && sameText(x.Length, y.Length)
+	}) {
+		fields = append(fields, FieldEnclosure)
+	}
+	if !sameDate(a.Time(), string(a.PubDate), b.Time(), string(b.PubDate)) {
+		fields = append(fields, FieldDate)
+	}
+	return fields
+}
+
+// EntryChange is an entry that was modified between two parses of an Atom feed.
+type EntryChange struct {
+	// Old is the entry of the old feed
+	Old *Entry
+
+	// New is the entry of the new feed
+	New *Entry
+
+	// Fields are the changed fields in the order title, content, enclosure, date
+	Fields []ChangedField
+}
+
+// Has reports if the field was changed.
+func (c *EntryChange) Has(field ChangedField) bool {
+	return slices.Contains(c.Fields, field)
+}
+
+// FeedDiff is the result of DiffAtom.
+type FeedDiff struct {
+	// Added are the entries of the new feed without a match in the old feed,
+	// in the order of the new feed
+	Added []Entry
+
+	// Removed are the entries of the old feed without a match in the new feed,
+	// in the order of the old feed
+	Removed []Entry
+
+	// Modified are the matching entries with changed fields,
+	// in the order of the new feed
+	Modified []EntryChange
+}
+
+// IsEmpty reports if the feeds have no differences.
+func (d *FeedDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// DiffAtom compares two parses of the same Atom feed and reports added,
+// removed and modified entries like DiffRegular. Entries are matched by key,
+// nil means DefaultEntryKey.
+func DiffAtom(oldFeed, newFeed *Feed, key func(entry *Entry) string) *FeedDiff {
+	if key == nil {
+		key = DefaultEntryKey
+	}
+	oldEntries := make(map[string]*Entry)
+	for i := range oldFeed.Entry {
+		if k := key(&oldFeed.Entry[i]); k != "" && oldEntries[k] == nil {
+			oldEntries[k] = &oldFeed.Entry[i]
+		}
+	}
+	diff := &FeedDiff{}
+	matched := make(map[string]bool)
+	for i := range newFeed.Entry {
+		newEntry := &newFeed.Entry[i]
+		k := key(newEntry)
+		oldEntry := oldEntries[k]
+		switch {
+		case oldEntry == nil:
+			diff.Added = append(diff.Added, *newEntry)
+		case !matched[k]:
+			matched[k] = true
+			if fields := entryChanges(oldEntry, newEntry); len(fields) > 0 {
+				diff.Modified = append(diff.Modified, EntryChange{Old: oldEntry, New: newEntry, Fields: fields})
+			}
+		}
+	}
+	for i := range oldFeed.Entry {
+		if k := key(&oldFeed.Entry[i]); k == "" || !matched[k] {
+			diff.Removed = append(diff.Removed, oldFeed.Entry[i])
+		}
+	}
+	return diff
+}
+
+// entryChanges returns the fields that differ between the entries.
+func entryChanges(a, b *Entry) []ChangedField {
+	var fields []ChangedField
+	if !sameText(a.Title, b.Title) {
+		fields = append(fields, FieldTitle)
+	}
+	if !sameContent(&a.Summary, &b.Summary) || !sameContent(&a.Content, &b.Content) {
+		fields = append(fields, FieldContent)
+	}
+	if !slices.EqualFunc(enclosureLinks(a.Link), enclosureLinks(b.Link), func(x, y Link) bool {
+		return sameText(x.Href, y.Href) && sameText(x.Type, y.Type) && sameText(x.Length, y.Length)
+	}) {
+		fields = append(fields, FieldEnclosure)
+	}
+	if !sameDate(a.UpdatedTime.Time, a.Updated, b.UpdatedTime.Time, b.Updated) ||
+		!sameDate(a.PublishedTime.Time, a.Published, b.PublishedTime.Time, b.Published) {
+		fields = append(fields, FieldDate)
+	}
+	return fields
+}
+
+// enclosureLinks returns the links with the relation "enclosure".
+func enclosureLinks(links []Link) []Link {
+	var enclosures []Link
+	for _, link := range links {
+		if hasLinkRel(link.Rel, "enclosure") {
+			enclosures = append(enclosures, link)
+		}
+	}
+	return enclosures
+}
+
+// sameContent reports if the contents have the same type, source and text.
+func sameContent(a, b *Content) bool {
+	return sameText(a.Type, b.Type) && sameText(a.Src, b.Src) && sameText(a.Body, b.Body)
+}
+
+// sameText reports if the strings are equal ignoring differences in whitespace.
+func sameText(a, b string) bool {
+	return a == b || strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+// sameDate reports if the dates are equal, comparing the times if both are
+// valid and the raw dates otherwise. The times are parsed from the raw dates
+// if they were not resolved during parsing.
+func sameDate(timeA time.Time, rawA string, timeB time.Time, rawB string) bool {
+	if timeA.IsZero() {
+		timeA, _ = Date(rawA).Parse()
+	}
+	if timeB.IsZero() {
+		timeB, _ = Date(rawB).Parse()
+	}
+	if !timeA.IsZero() && !timeB.IsZero() {
+		return timeA.Equal(timeB)
+	}
+	return sameText(rawA, rawB)
+}
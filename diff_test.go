@@ -0,0 +1,103 @@
+package rss
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffRegular(t *testing.T) {
+	oldChannel, err := ParseRegular(context.Background(), strings.NewReader(`<rss version="2.0"><channel>
+		<item><title>Kept</title><guid>1</guid><description>Same</description><pubDate>Tue, 05 Mar 2024 12:00:00 +0000</pubDate></item>
+		<item><title>Edited</title><guid>2</guid><description>Old text</description><pubDate>Tue, 05 Mar 2024 12:00:00 +0000</pubDate></item>
+		<item><title>Episode</title><guid>3</guid><enclosure url="https://example.com/3.mp3" type="audio/mpeg" length="100"/></item>
+		<item><title>Removed</title><guid>4</guid></item>
+	</channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newChannel, err := ParseRegular(context.Background(), strings.NewReader(`<rss version="2.0"><channel>
+		<item><title>Added</title><guid>5</guid></item>
+		<item><title>Kept</title><guid>1</guid><description>  Same
+			</description><pubDate>Tue, 05 Mar 2024 13:00:00 +0100</pubDate></item>
+		<item><title>Edited title</title><guid>2</guid><description>New text</description><pubDate>Wed, 06 Mar 2024 12:00:00 +0000</pubDate></item>
+		<item><title>Episode</title><guid>3</guid><enclosure url="https://example.com/3.mp3" type="audio/mpeg" length="200"/></item>
+	</channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := DiffRegular(oldChannel, newChannel, nil)
+	if diff.IsEmpty() {
+		t.Fatal("expected differences")
+	}
+	if got := itemTitles(&Channel{Item: diff.Added}); got != "Added" {
+		t.Errorf("Added = %q", got)
+	}
+	if got := itemTitles(&Channel{Item: diff.Removed}); got != "Removed" {
+		t.Errorf("Removed = %q", got)
+	}
+	if len(diff.Modified) != 2 {
+		t.Fatalf("expected 2 modified items, got %d", len(diff.Modified))
+	}
+	edited := diff.Modified[0]
+	if edited.Old.Title != "Edited" || edited.New.Title != "Edited title" {
+		t.Errorf("unexpected modified item %q -> %q", edited.Old.Title, edited.New.Title)
+	}
+	want := []ChangedField{FieldTitle, FieldContent, FieldDate}
+	if !slices.Equal(edited.Fields, want) {
+		t.Errorf("Fields = %v, want %v", edited.Fields, want)
+	}
+	if !diff.Modified[1].Has(FieldEnclosure) || len(diff.Modified[1].Fields) != 1 {
+		t.Errorf("Fields = %v, want enclosure", diff.Modified[1].Fields)
+	}
+
+	if diff := DiffRegular(newChannel, newChannel, nil); !diff.IsEmpty() {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+}
+
+func TestDiffRegularKey(t *testing.T) {
+	oldChannel := &Channel{Item: []Item{{Title: "Post", Link: "https://example.com/post?utm_source=a"}}}
+	newChannel := &Channel{Item: []Item{{Title: "Post", Link: "https://example.com/post?utm_source=b"}}}
+	if diff := DiffRegular(oldChannel, newChannel, nil); len(diff.Added) != 1 || len(diff.Removed) != 1 {
+		t.Errorf("expected added and removed item with default key, got %+v", diff)
+	}
+	key := func(item *Item) string {
+		return item.KeyWithOptions(KeyOptions{NormalizeURLs: true})
+	}
+	if diff := DiffRegular(oldChannel, newChannel, key); !diff.IsEmpty() {
+		t.Errorf("expected no differences with normalized key, got %+v", diff)
+	}
+}
+
+func TestDiffAtom(t *testing.T) {
+	oldFeed := &Feed{Entry: []Entry{
+		{ID: "1", Title: "Post", Updated: "2024-03-05T12:00:00Z", Content: Content{Type: "html", Body: "<p>Old</p>"}},
+		{ID: "2", Title: "Gone"},
+		{ID: "3", Title: "Podcast", Link: []Link{{Href: "https://example.com/3.mp3", Rel: "enclosure"}}},
+	}}
+	newFeed := &Feed{Entry: []Entry{
+		{ID: "3", Title: "Podcast", Link: []Link{{Href: "https://example.com/3.m4a", Rel: "enclosure"}}},
+		{ID: "1", Title: "Post", Updated: "2024-03-06T12:00:00Z", Content: Content{Type: "html", Body: "<p>New</p>"}},
+		{ID: "4", Title: "New"},
+	}}
+
+	diff := DiffAtom(oldFeed, newFeed, nil)
+	if got := entryTitles(&Feed{Entry: diff.Added}); got != "New" {
+		t.Errorf("Added = %q", got)
+	}
+	if got := entryTitles(&Feed{Entry: diff.Removed}); got != "Gone" {
+		t.Errorf("Removed = %q", got)
+	}
+	if len(diff.Modified) != 2 {
+		t.Fatalf("expected 2 modified entries, got %d", len(diff.Modified))
+	}
+	if got := diff.Modified[0].Fields; !slices.Equal(got, []ChangedField{FieldEnclosure}) {
+		t.Errorf("Fields = %v, want enclosure", got)
+	}
+	if got := diff.Modified[1].Fields; !slices.Equal(got, []ChangedField{FieldContent, FieldDate}) {
+		t.Errorf("Fields = %v, want content and date", got)
+	}
+}
@@ -0,0 +1,149 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	htmlparser "golang.org/x/net/html"
+)
+
+// feedMediaTypes maps the media types of feed links in HTML pages to their format.
+var feedMediaTypes = map[string]Format{
+	"application/rss+xml":   FormatRSS,
+	"application/rdf+xml":   FormatRSS,
+	"application/atom+xml":  FormatAtom,
+	"application/feed+json": FormatJSON,
+	"application/json":      FormatJSON,
+}
+
+// DiscoveredFeed is a feed found by Discover.
+type DiscoveredFeed struct {
+	// URL is the absolute URL of the feed
+	URL string
+
+	// Title is the title of the link to the feed, may be empty
+	Title string
+
+	// Format is the format announced by the type of the link
+	Format Format
+}
+
+// Discover finds the feeds of a web page using feed autodiscovery, that is
+// link elements with rel="alternate" and a feed media type like
+// application/rss+xml in the page. If the URL is a feed itself,
+// it is returned as the only result.
+//
+// A nil client means http.DefaultClient.
+//
+// Returns the discovered feeds in the order of the page,
+// which may be empty if the page links no feeds.
+func Discover(ctx context.Context, pageURL string, client *http.Client) ([]DiscoveredFeed, error) {
+	req, err := newRequest(ctx, pageURL, false)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := do(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", err)
+	}
+
+	// Resolve against the final URL after redirects
+	finalURL := req.URL
+	if resp.Request != nil {
+		finalURL = resp.Request.URL
+	}
+
+	// The page URL could be a feed itself
+	if format, err := detectFormat(data, resp.Header.Get("Content-Type")); err == nil {
+		return []DiscoveredFeed{{URL: finalURL.String(), Format: format}}, nil
+	}
+	return discoverLinks(bytes.NewReader(data), finalURL), nil
+}
+
+// discoverLinks returns the feeds linked in the head of an HTML page,
+// resolved against the base element of the page or the pageURL.
+func discoverLinks(r io.Reader, pageURL *url.URL) []DiscoveredFeed {
+	var (
+		feeds []DiscoveredFeed
+		seen  = make(map[string]bool)
+		base  = pageURL
+	)
+	tokenizer := htmlparser.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case htmlparser.ErrorToken:
+			return feeds
+		case htmlparser.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "head" {
+				return feeds
+			}
+		case htmlparser.StartTagToken, htmlparser.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return feeds
+			case "base":
+				if href, err := url.Parse(strings.TrimSpace(tokenAttr(token, "href"))); err == nil {
+					base = pageURL.ResolveReference(href)
+				}
+			case "link":
+				if !hasLinkRel(tokenAttr(token, "rel"), "alternate") {
+					continue
+				}
+				mediaType, _, _ := mime.ParseMediaType(tokenAttr(token, "type"))
+				format, ok := feedMediaTypes[strings.ToLower(mediaType)]
+				if !ok {
+					continue
+				}
+				href, err := url.Parse(strings.TrimSpace(tokenAttr(token, "href")))
+				if err != nil || tokenAttr(token, "href") == "" {
+					continue
+				}
+				feedURL := base.ResolveReference(href).String()
+				if seen[feedURL] {
+					continue
+				}
+				seen[feedURL] = true
+				feeds = append(feeds, DiscoveredFeed{
+					URL:    feedURL,
+					Title:  strings.TrimSpace(tokenAttr(token, "title")),
+					Format: format,
+				})
+			}
+		}
+	}
+}
+
+// hasLinkRel checks if the space separated rel attribute value contains rel.
+func hasLinkRel(rels, rel string) bool {
+	for _, r := range strings.Fields(rels) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenAttr(token htmlparser.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDiscoveryPage = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Blog</title>
+	<base href="/blog/">
+	<link rel="stylesheet" href="style.css">
+	<link rel="alternate" type="application/rss+xml" title="RSS" href="feed.rss">
+	<link rel="alternate" type="application/atom+xml" title="Atom" href="https://example.com/feed.atom">
+	<link rel="alternate" type="application/feed+json" title="JSON" href="feed.json">
+	<link rel="alternate" type="application/rss+xml" title="Duplicate" href="/blog/feed.rss">
+	<link rel="alternate" hreflang="de" href="/de/">
+</head>
+<body>
+	<link rel="alternate" type="application/rss+xml" href="ignored.rss">
+</body>
+</html>`
+
+// TestDiscover tests feed autodiscovery in HTML pages
+func TestDiscover(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(testDiscoveryPage))
+	})
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss version="2.0"><channel><title>Feed</title></channel></rss>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	feeds, err := Discover(context.Background(), server.URL+"/page", server.Client())
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	expected := []DiscoveredFeed{
+		{URL: server.URL + "/blog/feed.rss", Title: "RSS", Format: FormatRSS},
+		{URL: "https://example.com/feed.atom", Title: "Atom", Format: FormatAtom},
+		{URL: server.URL + "/blog/feed.json", Title: "JSON", Format: FormatJSON},
+	}
+	if len(feeds) != len(expected) {
+		t.Fatalf("Expected %d feeds, got %+v", len(expected), feeds)
+	}
+	for i := range expected {
+		if feeds[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], feeds[i])
+		}
+	}
+
+	feeds, err = Discover(context.Background(), server.URL+"/feed", server.Client())
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(feeds) != 1 || feeds[0].URL != server.URL+"/feed" || feeds[0].Format != FormatRSS {
+		t.Errorf("Expected the feed URL itself, got %+v", feeds)
+	}
+}
+
+// TestDiscoverNoFeeds tests pages without feed links and failing requests
+func TestDiscoverNoFeeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/page" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" hreflang="de" href="/de/"></head><body>No feeds</body></html>`))
+	}))
+	defer server.Close()
+
+	// A nil client uses http.DefaultClient
+	feeds, err := Discover(context.Background(), server.URL+"/page", nil)
+	if err != nil || len(feeds) != 0 {
+		t.Errorf("Expected no feeds, got %+v, %v", feeds, err)
+	}
+	if _, err := Discover(context.Background(), server.URL+"/missing", nil); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("Expected HTTP 404 error, got %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrSizeMismatch is returned by Downloader if the size of a download
+	// differs from the declared length of the enclosure or the Content-Length.
+	ErrSizeMismatch = errors.New("download size mismatch")
+
+	// ErrContentType is returned by Downloader if the server responds
+	// with a content type that does not match the enclosure.
+	ErrContentType = errors.New("unexpected content type")
+)
+
+const (
+	// partSuffix is appended to the path of incomplete downloads.
+	partSuffix = ".part"
+
+	// validatorSuffix is appended to the path of a part file for the file
+	// storing the ETag or Last-Modified date of the download.
+	validatorSuffix = ".etag"
+)
+
+// DownloadProgress is reported by Downloader while downloading an enclosure.
+type DownloadProgress struct {
+	// URL is the URL of the enclosure
+	URL string
+
+	// Downloaded is the number of bytes downloaded so far,
+	// including the bytes of a resumed earlier download
+	Downloaded int64
+
+	// Total is the size of the file in bytes, 0 if unknown
+	Total int64
+}
+
+// DownloadJob is an enclosure to download to a file, see Downloader.DownloadFiles.
+type DownloadJob struct {
+	Enclosure Enclosure
+	Path      string
+}
+
+// Downloader downloads enclosures like podcast episodes.
+//
+// Downloads are verified against the declared length of the enclosure,
+// the Content-Length of the response and the MIME type of the enclosure.
+// Files are downloaded to a ".part" file next to the destination that is
+// renamed when complete, so an interrupted download is resumed with an
+// HTTP Range request the next time. The ETag or Last-Modified date of the
+// response is stored next to the part file and sent as If-Range when
+// resuming, so a file that changed on the server is downloaded again
+// instead of being appended to the old part.
+type Downloader struct {
+	// Client is used for the downloads, nil means http.DefaultClient
+	Client *http.Client
+
+	// Concurrency is the maximum number of downloads at the same time
+	// by DownloadFiles, zero means 4
+	Concurrency int
+
+	// ContentTypes are the accepted MIME types of responses like "audio/mpeg" or "audio/*".
+	// If empty, the type of the enclosure or its medium is expected if declared.
+	// Responses with the generic type application/octet-stream are always accepted
+	// and HTML pages are only accepted if explicitly configured.
+	ContentTypes []string
+
+	// IgnoreLength disables the check of the declared length of enclosures,
+	// which is often inaccurate in feeds. The Content-Length is always checked.
+	IgnoreLength bool
+
+	// OnProgress is called with the progress of downloads if not nil.
+	// It is called concurrently by DownloadFiles.
+	OnProgress func(progress DownloadProgress)
+}
+
+// Download streams the enclosure to w and returns the number of bytes written.
+// Downloads to writers can't be resumed, use DownloadFile for that.
+//
+// Returns an error wrapping ErrSizeMismatch or ErrContentType if the download
+// fails verification, in which case data may have been written to w already.
+func (d *Downloader) Download(ctx context.Context, enclosure Enclosure, w io.Writer) (int64, error) {
+	resp, err := d.get(ctx, enclosure, 0, "")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return d.copy(enclosure, w, resp, 0)
+}
+
+// DownloadFile downloads the enclosure to the file at path.
+//
+// The data is written to path+".part" first. If that file exists from an
+// interrupted download, the download is resumed with a Range request,
+// or restarted if the server does not support ranges. The ETag or
+// Last-Modified date of the response is kept in path+".part.etag" and sent
+// as If-Range when resuming, so the download restarts if the file changed.
+// The part file is renamed to path after the download was verified, and
+// removed if the download is invalid. It is kept for resuming if the
+// download was interrupted or canceled.
+func (d *Downloader) DownloadFile(ctx context.Context, enclosure Enclosure, path string) error {
+	partPath := path + partSuffix
+	validatorPath := partPath + validatorSuffix
+	var (
+		offset    int64
+		validator string
+	)
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to resume download: %w", err)
+	}
+	if offset > 0 {
+		data, err := os.ReadFile(validatorPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to resume download: %w", err)
+		}
+		validator = strings.TrimSpace(string(data))
+	}
+
+	resp, err := d.get(ctx, enclosure, offset, validator)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	switch resp.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The part file is already complete if it has the size of the file
+		complete := resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset)
+		if complete || !d.IgnoreLength && enclosure.Length > 0 && offset == enclosure.Length {
+			return d.complete(partPath, path)
+		}
+		removePart(partPath)
+		return fmt.Errorf("failed to resume download of %s: HTTP %d", enclosure.URL, resp.StatusCode)
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return fmt.Errorf("failed to resume download of %s: invalid Content-Range %q", enclosure.URL, resp.Header.Get("Content-Range"))
+		}
+	default:
+		// The server sends the whole file, also if it changed since the part was downloaded
+		offset = 0
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if err := writeValidator(validatorPath, resp.Header); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create download file: %w", err)
+	}
+	_, err = d.copy(enclosure, file, resp, offset)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write download file: %w", closeErr)
+	}
+	if err != nil {
+		if errors.Is(err, ErrSizeMismatch) || errors.Is(err, ErrContentType) {
+			removePart(partPath)
+		}
+		return err
+	}
+	return d.complete(partPath, path)
+}
+
+// DownloadFiles downloads the jobs with DownloadFile,
+// running up to Concurrency downloads at the same time.
+//
+// Returns the joined errors of the failed downloads.
+func (d *Downloader) DownloadFiles(ctx context.Context, jobs []DownloadJob) error {
+	concurrency := d.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	var (
+		wg   sync.WaitGroup
+		mtx  sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, job := range jobs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := d.DownloadFile(ctx, job.Enclosure, job.Path); err != nil {
+				mtx.Lock()
+				errs = append(errs, fmt.Errorf("failed to download %s: %w", job.Enclosure.URL, err))
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// FileName returns the unescaped last path segment of the enclosure URL
+// usable as file name, or "enclosure" if the URL has no such segment.
+// Characters that are invalid in file names on common systems are replaced with "_".
+func (e *Enclosure) FileName() string {
+	var name string
+	if u, err := url.Parse(e.URL); err == nil {
+		name = path.Base(u.Path)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." || name == "_" {
+		return "enclosure"
+	}
+	return name
+}
+
+// get requests the enclosure starting at offset. If validator is not empty,
+// it is sent as If-Range so the server responds with the whole file if it changed.
+func (d *Downloader) get(ctx context.Context, enclosure Enclosure, offset int64, validator string) (*http.Response, error) {
+	req, err := newRequest(ctx, enclosure.URL, false)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", redactError(err))
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		return resp, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	if err := d.verifyContentType(enclosure, resp.Header.Get("Content-Type")); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// copy writes the body of the response to w reporting the progress,
+// and verifies the size of the download. Offset is the number of bytes
+// downloaded before the response.
+func (d *Downloader) copy(enclosure Enclosure, w io.Writer, resp *http.Response, offset int64) (int64, error) {
+	var expected int64
+	if resp.ContentLength >= 0 {
+		expected = offset + resp.ContentLength
+	}
+	if !d.IgnoreLength && enclosure.Length > 0 {
+		if expected > 0 && expected != enclosure.Length {
+			return 0, fmt.Errorf("%w: %s has %d bytes, declared length is %d", ErrSizeMismatch, enclosure.URL, expected, enclosure.Length)
+		}
+		expected = enclosure.Length
+	}
+
+	progress := DownloadProgress{URL: enclosure.URL, Downloaded: offset, Total: expected}
+	buf := make([]byte, 32<<10)
+	var written int64
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return written, fmt.Errorf("failed to write download: %w", err)
+			}
+			written += int64(n)
+			progress.Downloaded += int64(n)
+			if d.OnProgress != nil {
+				d.OnProgress(progress)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return written, fmt.Errorf("failed to download %s: %w", enclosure.URL, readErr)
+		}
+	}
+
+	if expected > 0 && progress.Downloaded != expected {
+		return written, fmt.Errorf("%w: downloaded %d of %d bytes of %s", ErrSizeMismatch, progress.Downloaded, expected, enclosure.URL)
+	}
+	return written, nil
+}
+
+// complete renames the verified part file to path.
+func (d *Downloader) complete(partPath, path string) error {
+	if err := os.Rename(partPath, path); err != nil {
+		return fmt.Errorf("failed to complete download: %w", err)
+	}
+	os.Remove(partPath + validatorSuffix)
+	return nil
+}
+
+// removePart removes an invalid part file and its validator.
+func removePart(partPath string) {
+	os.Remove(partPath)
+	os.Remove(partPath + validatorSuffix)
+}
+
+// writeValidator stores the strong ETag or the Last-Modified date of a response
+// for the part file, or removes a stored one if the response has neither.
+// Weak ETags can't be used with If-Range.
+func writeValidator(validatorPath string, header http.Header) error {
+	validator := header.Get("ETag")
+	if strings.HasPrefix(validator, "W/") {
+		validator = ""
+	}
+	if validator == "" {
+		validator = header.Get("Last-Modified")
+	}
+	if validator == "" {
+		if err := os.Remove(validatorPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove download validator: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(validatorPath, []byte(validator), 0o644); err != nil {
+		return fmt.Errorf("failed to write download validator: %w", err)
+	}
+	return nil
+}
+
+// verifyContentType checks the content type of a response for the enclosure.
+func (d *Downloader) verifyContentType(enclosure Enclosure, contentType string) error {
+	actual, _, err := mime.ParseMediaType(contentType)
+	if err != nil || actual == "application/octet-stream" || actual == "binary/octet-stream" {
+		// A missing or generic type proves nothing
+		return nil
+	}
+	accepted := d.ContentTypes
+	if len(accepted) == 0 && enclosure.Type != "" && enclosure.Type != "application/octet-stream" {
+		accepted = []string{enclosure.Type, enclosure.Medium() + "/*"}
+	}
+	if len(accepted) == 0 && actual != "text/html" {
+		return nil
+	}
+	for _, pattern := range accepted {
+		if matchMediaType(pattern, actual) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q for %s", ErrContentType, actual, enclosure.URL)
+}
+
+// matchMediaType reports if the media type matches the pattern,
+// which may have a wildcard subtype like "audio/*".
+func matchMediaType(pattern, mediaType string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return pattern == mediaType
+}
+
+// contentRangeStart returns the first byte position of a Content-Range header
+// like "bytes 100-199/200".
+func contentRangeStart(contentRange string) (int64, error) {
+	rangeSpec, ok := strings.CutPrefix(strings.TrimSpace(contentRange), "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	start, _, _ := strings.Cut(rangeSpec, "-")
+	return strconv.ParseInt(start, 10, 64)
+}
@@ -0,0 +1,290 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testEpisode is the content of the enclosures served by downloadServer.
+var testEpisode = bytes.Repeat([]byte("0123456789"), 10000)
+
+// downloadServer serves testEpisode with Range support at /episode.mp3,
+// an HTML error page at /error.mp3 and records the Range headers.
+func downloadServer(t *testing.T) (*httptest.Server, *[]string) {
+	var (
+		mtx    sync.Mutex
+		ranges []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mtx.Unlock()
+		switch r.URL.Path {
+		case "/error.mp3":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><body>Not found</body></html>"))
+		default:
+			http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(testEpisode))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+func TestDownload(t *testing.T) {
+	server, _ := downloadServer(t)
+	var progress []DownloadProgress
+	downloader := &Downloader{OnProgress: func(p DownloadProgress) {
+		progress = append(progress, p)
+	}}
+	enclosure := Enclosure{URL: server.URL + "/episode.mp3", Type: "audio/mpeg", Length: int64(len(testEpisode))}
+
+	var buf bytes.Buffer
+	n, err := downloader.Download(context.Background(), enclosure, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(testEpisode)) || !bytes.Equal(buf.Bytes(), testEpisode) {
+		t.Errorf("downloaded %d bytes, want %d", n, len(testEpisode))
+	}
+	if len(progress) == 0 {
+		t.Fatal("no progress reported")
+	}
+	last := progress[len(progress)-1]
+	if last.Downloaded != int64(len(testEpisode)) || last.Total != int64(len(testEpisode)) || last.URL != enclosure.URL {
+		t.Errorf("unexpected last progress %+v", last)
+	}
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	server, ranges := downloadServer(t)
+	path := filepath.Join(t.TempDir(), "episode.mp3")
+	half := len(testEpisode) / 2
+	if err := os.WriteFile(path+partSuffix, testEpisode[:half], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first DownloadProgress
+	downloader := &Downloader{OnProgress: func(p DownloadProgress) {
+		if first.URL == "" {
+			first = p
+		}
+	}}
+	enclosure := Enclosure{URL: server.URL + "/episode.mp3", Length: int64(len(testEpisode))}
+	if err := downloader.DownloadFile(context.Background(), enclosure, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, testEpisode) {
+		t.Errorf("downloaded file has %d bytes, want %d", len(data), len(testEpisode))
+	}
+	if _, err := os.Stat(path + partSuffix); !os.IsNotExist(err) {
+		t.Errorf("part file was not removed: %v", err)
+	}
+	if got := strings.Join(*ranges, ","); got != "bytes=50000-" {
+		t.Errorf("Range headers = %q", got)
+	}
+	if first.Downloaded <= int64(half) {
+		t.Errorf("progress must include resumed bytes, got %+v", first)
+	}
+
+	// A complete part file is renamed without downloading again
+	if err := os.WriteFile(path+partSuffix, testEpisode, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := downloader.DownloadFile(context.Background(), enclosure, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + partSuffix); !os.IsNotExist(err) {
+		t.Errorf("complete part file was not renamed: %v", err)
+	}
+}
+
+func TestDownloadFileChanged(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		etag     = `"v1"`
+		content  = testEpisode
+		ifRanges []string
+	)
+	changed := bytes.Repeat([]byte("abcdefghij"), 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+		w.Header().Set("ETag", etag)
+		if len(ifRanges) == 1 {
+			// Interrupt the first download after half of the file
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/2])
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "episode.mp3")
+	downloader := &Downloader{}
+	enclosure := Enclosure{URL: server.URL + "/episode.mp3"}
+	if err := downloader.DownloadFile(context.Background(), enclosure, path); err == nil {
+		t.Fatal("Expected error for interrupted download")
+	}
+	if validator, err := os.ReadFile(path + partSuffix + validatorSuffix); err != nil || string(validator) != `"v1"` {
+		t.Fatalf("Expected stored ETag, got %q, %v", validator, err)
+	}
+
+	// The file changed on the server, so the whole new file is downloaded
+	mtx.Lock()
+	etag, content = `"v2"`, changed
+	mtx.Unlock()
+	if err := downloader.DownloadFile(context.Background(), enclosure, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, changed) {
+		t.Errorf("Expected the changed file, got %d bytes starting with %q", len(data), data[:10])
+	}
+	if got := strings.Join(ifRanges, ","); got != `,"v1"` {
+		t.Errorf("If-Range headers = %q", got)
+	}
+	for _, name := range []string{path + partSuffix, path + partSuffix + validatorSuffix} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed: %v", name, err)
+		}
+	}
+}
+
+func TestDownloadFileVerification(t *testing.T) {
+	server, _ := downloadServer(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		downloader *Downloader
+		enclosure  Enclosure
+		wantErr    error
+	}{
+		{
+			name:       "declared length",
+			downloader: &Downloader{},
+			enclosure:  Enclosure{URL: server.URL + "/episode.mp3", Length: 1234},
+			wantErr:    ErrSizeMismatch,
+		},
+		{
+			name:       "ignored length",
+			downloader: &Downloader{IgnoreLength: true},
+			enclosure:  Enclosure{URL: server.URL + "/episode.mp3", Length: 1234},
+		},
+		{
+			name:       "html error page",
+			downloader: &Downloader{},
+			enclosure:  Enclosure{URL: server.URL + "/error.mp3"},
+			wantErr:    ErrContentType,
+		},
+		{
+			name:       "enclosure type",
+			downloader: &Downloader{},
+			enclosure:  Enclosure{URL: server.URL + "/episode.mp3", Type: "video/mp4"},
+			wantErr:    ErrContentType,
+		},
+		{
+			name:       "configured types",
+			downloader: &Downloader{ContentTypes: []string{"audio/*"}},
+			enclosure:  Enclosure{URL: server.URL + "/episode.mp3", Type: "video/mp4"},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.enclosure.FileName()+string(rune('a'+i)))
+			err := tt.downloader.DownloadFile(ctx, tt.enclosure, path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DownloadFile() err = %v, want %v", err, tt.wantErr)
+			}
+			_, statErr := os.Stat(path)
+			if tt.wantErr != nil && !os.IsNotExist(statErr) {
+				t.Errorf("invalid download was kept")
+			}
+			if tt.wantErr == nil && statErr != nil {
+				t.Errorf("download missing: %v", statErr)
+			}
+			if _, err := os.Stat(path + partSuffix); !os.IsNotExist(err) {
+				t.Errorf("part file was not removed")
+			}
+		})
+	}
+}
+
+func TestDownloadFiles(t *testing.T) {
+	var (
+		mtx               sync.Mutex
+		active, maxActive int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		mtx.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mtx.Lock()
+		active--
+		mtx.Unlock()
+		if r.URL.Path == "/missing.mp3" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(testEpisode))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var jobs []DownloadJob
+	for _, name := range []string{"1.mp3", "2.mp3", "3.mp3", "missing.mp3", "5.mp3"} {
+		enclosure := Enclosure{URL: server.URL + "/" + name}
+		jobs = append(jobs, DownloadJob{Enclosure: enclosure, Path: filepath.Join(dir, enclosure.FileName())})
+	}
+	downloader := &Downloader{Concurrency: 2}
+	err := downloader.DownloadFiles(context.Background(), jobs)
+	if err == nil || !strings.Contains(err.Error(), "missing.mp3") || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("expected error for missing.mp3, got %v", err)
+	}
+	if maxActive > 2 {
+		t.Errorf("expected at most 2 concurrent downloads, got %d", maxActive)
+	}
+	for _, name := range []string{"1.mp3", "2.mp3", "3.mp3", "5.mp3"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() != int64(len(testEpisode)) {
+			t.Errorf("download of %s incomplete: %v", name, err)
+		}
+	}
+}
+
+func TestEnclosureFileName(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/pod/episode%201.mp3?x=1": "episode 1.mp3",
+		"https://example.com/":                        "enclosure",
+		"https://example.com/a%3Ab.mp3":               "a_b.mp3",
+		"":                                            "enclosure",
+	}
+	for rawURL, want := range tests {
+		if got := (&Enclosure{URL: rawURL}).FileName(); got != want {
+			t.Errorf("FileName(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
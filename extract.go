@@ -0,0 +1,468 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	htmlparser "golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNoArticle is returned by Extractor if a page has no recognizable article content.
+var ErrNoArticle = errors.New("no article content found")
+
+var (
+	// unlikelyNames match class and id attributes of page elements that are not part of articles
+	unlikelyNames = regexp.MustCompile(`(?i)\bads?\b|-ad-|advert|banner|breadcrumb|combx|comment|community|cookie|disqus|footer|gdpr|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|replies|share|shoutbox|sidebar|social|sponsor|subscribe|widget`)
+
+	// maybeNames keep elements matching unlikelyNames
+	maybeNames = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+
+	// positiveNames and negativeNames weight the class and id attributes of candidates
+	positiveNames = regexp.MustCompile(`(?i)article|blog|body|content|entry|h-entry|hentry|main|page|post|story|text`)
+	negativeNames = regexp.MustCompile(`(?i)-ad-|banner|combx|comment|footer|footnote|hidden|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// extractRemovedElements are removed including their content before scoring.
+var extractRemovedElements = map[string]bool{
+	"aside": true, "button": true, "canvas": true, "embed": true, "footer": true,
+	"form": true, "header": true, "iframe": true, "input": true, "link": true,
+	"meta": true, "nav": true, "noscript": true, "object": true, "script": true,
+	"select": true, "style": true, "svg": true, "template": true, "textarea": true,
+}
+
+// blockElements are elements that make a div more than a paragraph.
+var blockElements = map[string]bool{
+	"article": true, "blockquote": true, "div": true, "dl": true, "figure": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// Extractor fetches the web pages of items and extracts their main article
+// content, for feeds that carry only short descriptions.
+//
+// The content is found with a readability-style scoring: navigation, ads,
+// comments and similar page elements are removed, paragraphs are scored by
+// their length and number of commas, and the element containing the highest
+// scoring paragraphs is taken as the article, together with related siblings.
+type Extractor struct {
+	// Client is used to fetch pages, nil means http.DefaultClient
+	Client *http.Client
+
+	// Policy sanitizes the extracted HTML, nil means DefaultSanitizePolicy
+	Policy *SanitizePolicy
+
+	// MinTextLength is the minimum number of characters of the text of an article,
+	// shorter content results in ErrNoArticle. Zero means 250.
+	MinTextLength int
+
+	// MaxPageSize is the maximum number of bytes read of a page, zero means 5 MiB
+	MaxPageSize int64
+
+	// Concurrency is the maximum number of pages fetched at the same time
+	// by ExtractChannel, zero means 4
+	Concurrency int
+}
+
+// Extract fetches the page at pageURL and returns the sanitized HTML of its
+// main article content. Relative URLs in the content are resolved.
+//
+// Returns an error wrapping ErrNoArticle if the page has no article content,
+// or an error if the page is not HTML.
+func (e *Extractor) Extract(ctx context.Context, pageURL string) (string, error) {
+	req, err := newRequest(ctx, pageURL, false)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := do(client, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", fmt.Errorf("failed to extract article: unsupported content type %q", mediaType)
+	}
+
+	maxSize := e.MaxPageSize
+	if maxSize <= 0 {
+		maxSize = 5 << 20
+	}
+	r, err := charset.NewReader(io.LimitReader(resp.Body, maxSize), contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to read page: %w", err)
+	}
+
+	// Resolve against the final URL after redirects
+	finalURL := req.URL
+	if resp.Request != nil {
+		finalURL = resp.Request.URL
+	}
+	return e.ExtractHTML(r, finalURL.String())
+}
+
+// ExtractHTML returns the sanitized HTML of the main article content
+// of the UTF-8 encoded HTML page read from r, see Extract.
+// Relative URLs are resolved against the base element of the page or pageURL,
+// which may be empty or relative. Returns an error if pageURL can not be parsed.
+func (e *Extractor) ExtractHTML(r io.Reader, pageURL string) (string, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid page URL: %w", err)
+	}
+	doc, err := htmlparser.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse page: %w", err)
+	}
+	if href := findBaseHref(doc); href != "" {
+		base = resolveBase(base, href)
+	}
+
+	body := findElement(doc, "body")
+	if body == nil {
+		return "", ErrNoArticle
+	}
+	prepareArticle(body)
+	nodes := articleNodes(body)
+
+	var b strings.Builder
+	for _, node := range nodes {
+		cleanArticle(node)
+		if node == body {
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				htmlparser.Render(&b, child)
+			}
+			continue
+		}
+		htmlparser.Render(&b, node)
+	}
+
+	policy := e.Policy
+	if policy == nil {
+		policy = DefaultSanitizePolicy()
+	}
+	content := policy.Sanitize(resolveHTMLURLs(base, b.String()))
+
+	minLength := e.MinTextLength
+	if minLength <= 0 {
+		minLength = 250
+	}
+	if utf8.RuneCountInString(HTMLToText(content)) < minLength {
+		return "", ErrNoArticle
+	}
+	return content, nil
+}
+
+// ExtractItem extracts the article of the item's link into its FullText.
+// The item is not changed if the extraction fails.
+func (e *Extractor) ExtractItem(ctx context.Context, item *Item) error {
+	if item.Link == "" {
+		return fmt.Errorf("failed to extract article: item has no link")
+	}
+	content, err := e.Extract(ctx, item.Link)
+	if err != nil {
+		return err
+	}
+	item.FullText = content
+	return nil
+}
+
+// ExtractChannel extracts the articles of all items of the channel that have
+// a link and no FullText yet, fetching up to Concurrency pages at the same time.
+//
+// Returns the joined errors of the items that failed,
+// the other items are populated nevertheless.
+func (e *Extractor) ExtractChannel(ctx context.Context, channel *Channel) error {
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	var (
+		wg   sync.WaitGroup
+		mtx  sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+	for i := range channel.Item {
+		item := &channel.Item[i]
+		if item.Link == "" || item.FullText != "" {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := e.ExtractItem(ctx, item); err != nil {
+				mtx.Lock()
+				errs = append(errs, fmt.Errorf("failed to extract %s: %w", item.Link, err))
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// prepareArticle removes elements that are not part of the article,
+// like scripts, navigation, hidden elements, and elements with class or
+// id attributes of ads, comments, sidebars and the like.
+func prepareArticle(node *htmlparser.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == htmlparser.CommentNode:
+			node.RemoveChild(child)
+		case child.Type != htmlparser.ElementNode:
+		case extractRemovedElements[child.Data] || isHidden(child) || isUnlikely(child):
+			node.RemoveChild(child)
+		default:
+			prepareArticle(child)
+		}
+		child = next
+	}
+}
+
+func isHidden(node *htmlparser.Node) bool {
+	for _, attr := range node.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			return attr.Val == "true"
+		case "style":
+			style := strings.ReplaceAll(strings.ToLower(attr.Val), " ", "")
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isUnlikely(node *htmlparser.Node) bool {
+	switch node.Data {
+	case "article", "body", "main", "a":
+		return false
+	}
+	names := nodeAttr(node, "class") + " " + nodeAttr(node, "id")
+	return unlikelyNames.MatchString(names) && !maybeNames.MatchString(names)
+}
+
+// articleNodes returns the element with the highest score
+// and its siblings that are likely part of the article.
+func articleNodes(body *htmlparser.Node) []*htmlparser.Node {
+	scores := make(map[*htmlparser.Node]float64)
+	var candidates []*htmlparser.Node
+	addScore := func(node *htmlparser.Node, score float64) {
+		if node == nil || node.Type != htmlparser.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = initialScore(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+
+	var walk func(*htmlparser.Node)
+	walk = func(node *htmlparser.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != htmlparser.ElementNode {
+				continue
+			}
+			if !isParagraph(child) {
+				walk(child)
+				continue
+			}
+			text := strings.TrimSpace(whitespacePattern.ReplaceAllString(nodeText(child), " "))
+			length := utf8.RuneCountInString(text)
+			if length < 25 {
+				continue
+			}
+			score := 1 + float64(strings.Count(text, ",")) + min(float64(length/100), 3)
+			addScore(child.Parent, score)
+			if child.Parent != nil && child.Parent != body {
+				addScore(child.Parent.Parent, score/2)
+			}
+		}
+	}
+	walk(body)
+	if len(candidates) == 0 {
+		return []*htmlparser.Node{body}
+	}
+
+	var top *htmlparser.Node
+	for _, candidate := range candidates {
+		scores[candidate] *= 1 - linkDensity(candidate)
+		if top == nil || scores[candidate] > scores[top] {
+			top = candidate
+		}
+	}
+	if top == body || top.Parent == nil {
+		return []*htmlparser.Node{top}
+	}
+
+	threshold := max(10, scores[top]*0.2)
+	var nodes []*htmlparser.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != htmlparser.ElementNode {
+			continue
+		}
+		if score, ok := scores[sibling]; sibling == top || ok && score >= threshold {
+			nodes = append(nodes, sibling)
+			continue
+		}
+		if sibling.Data == "p" {
+			text := strings.TrimSpace(nodeText(sibling))
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(sibling)
+			if length > 80 && density < 0.25 || length > 0 && density == 0 && strings.HasSuffix(text, ".") {
+				nodes = append(nodes, sibling)
+			}
+		}
+	}
+	return nodes
+}
+
+// isParagraph reports if the element is scored as a paragraph,
+// which includes divs without block elements.
+func isParagraph(node *htmlparser.Node) bool {
+	switch node.Data {
+	case "p", "pre", "td":
+		return true
+	case "div":
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == htmlparser.ElementNode && blockElements[child.Data] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// initialScore returns the score of a candidate by its element and its class and id.
+func initialScore(node *htmlparser.Node) float64 {
+	var score float64
+	switch node.Data {
+	case "article", "main":
+		score = 10
+	case "div":
+		score = 5
+	case "blockquote", "pre", "td":
+		score = 3
+	case "dl", "form", "li", "ol", "ul":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+	return score + classWeight(node)
+}
+
+// classWeight returns a positive weight for class and id attributes typical
+// for articles and a negative weight for those of other page elements.
+func classWeight(node *htmlparser.Node) float64 {
+	var weight float64
+	for _, name := range []string{nodeAttr(node, "class"), nodeAttr(node, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeNames.MatchString(name) {
+			weight -= 25
+		}
+		if positiveNames.MatchString(name) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity returns the fraction of the text of the element that is link text.
+func linkDensity(node *htmlparser.Node) float64 {
+	length := utf8.RuneCountInString(strings.TrimSpace(nodeText(node)))
+	if length == 0 {
+		return 0
+	}
+	var linkLength int
+	var walk func(*htmlparser.Node)
+	walk = func(n *htmlparser.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == htmlparser.ElementNode && child.Data == "a" {
+				linkLength += utf8.RuneCountInString(strings.TrimSpace(nodeText(child)))
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(node)
+	return float64(linkLength) / float64(length)
+}
+
+// cleanArticle removes lists, tables and divs of the article that are mostly
+// links, like lists of related articles, or that have a negative class weight
+// and little text.
+func cleanArticle(node *htmlparser.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == htmlparser.ElementNode {
+			switch child.Data {
+			case "div", "ol", "section", "table", "ul":
+				length := utf8.RuneCountInString(strings.TrimSpace(nodeText(child)))
+				if linkDensity(child) > 0.5 || classWeight(child) < 0 && length < 200 {
+					node.RemoveChild(child)
+					child = next
+					continue
+				}
+			}
+			cleanArticle(child)
+		}
+		child = next
+	}
+}
+
+// findElement returns the first element with the name in document order.
+func findElement(node *htmlparser.Node, name string) *htmlparser.Node {
+	if node.Type == htmlparser.ElementNode && node.Data == name {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findBaseHref returns the href of the base element of the page.
+func findBaseHref(doc *htmlparser.Node) string {
+	if head := findElement(doc, "head"); head != nil {
+		if base := findElement(head, "base"); base != nil {
+			return nodeAttr(base, "href")
+		}
+	}
+	return ""
+}
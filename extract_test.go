@@ -0,0 +1,149 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testArticlePage = `<!DOCTYPE html>
+<html><head><title>Startup raises money</title><script>var tracking = 1;</script></head>
+<body>
+	<header><a href="/">TechCrunch</a></header>
+	<nav><ul><li><a href="/europe">Europe</a></li><li><a href="/startups">Startups</a></li></ul></nav>
+	<div id="content">
+		<div class="share-buttons"><a href="https://twitter.com/share">Tweet</a></div>
+		<article class="post-content">
+			<h1>Startup raises money</h1>
+			<p>The Berlin based startup announced on Thursday that it has raised a new round of funding, led by a European venture capital firm, to expand into new markets.</p>
+			<p>According to the founders, the money will be used to hire engineers, open an office in London, and grow the sales team over the next eighteen months.</p>
+			<img src="/images/team.jpg" alt="The team">
+			<p>The company, which was founded in 2012, says it now has more than a thousand paying customers, most of them small and medium sized businesses.</p>
+			<div class="related"><ul><li><a href="/other">Another startup raises money</a></li><li><a href="/more">More news</a></li></ul></div>
+		</article>
+		<aside class="sidebar"><p>Subscribe to our newsletter for the latest startup news, delivered every day, straight to your inbox.</p></aside>
+		<div id="comments"><p>Great article, thanks for sharing all these details about the funding round!</p></div>
+	</div>
+	<footer><p>Copyright, all rights reserved, no part may be reproduced without permission of the publisher.</p></footer>
+</body></html>`
+
+func TestExtractHTML(t *testing.T) {
+	extractor := &Extractor{}
+	content, err := extractor.ExtractHTML(strings.NewReader(testArticlePage), "http://techcrunch.com/2014/02/27/startup/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"The Berlin based startup announced",
+		"hire engineers",
+		"more than a thousand paying customers",
+		`<img src="http://techcrunch.com/images/team.jpg" alt="The team"/>`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content does not contain %q:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{">Europe<", "Tweet", "Another startup", "newsletter", "Great article", "Copyright", "tracking"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("content contains %q:\n%s", unwanted, content)
+		}
+	}
+}
+
+func TestExtractHTMLNoArticle(t *testing.T) {
+	page := `<html><body><nav><a href="/">Home</a></nav><p>Page not found.</p></body></html>`
+	_, err := (&Extractor{}).ExtractHTML(strings.NewReader(page), "https://example.com/")
+	if !errors.Is(err, ErrNoArticle) {
+		t.Errorf("expected ErrNoArticle, got %v", err)
+	}
+}
+
+func TestExtractHTMLRelativePageURL(t *testing.T) {
+	page := `<html><head><base href="/blog/"></head><body><article xml:base="2024/">` +
+		strings.Repeat("<p>A paragraph of the article that is long enough to count as content.</p>", 5) +
+		`<p><img src="photo.jpg" alt="Photo"></p></article></body></html>`
+	content, err := (&Extractor{}).ExtractHTML(strings.NewReader(page), "articles/1.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, `<img src="/blog/2024/photo.jpg" alt="Photo"/>`) {
+		t.Errorf("expected image relative to the base element:\n%s", content)
+	}
+
+	// Invalid page URLs are an error instead of leaving URLs unresolved
+	if _, err := (&Extractor{}).ExtractHTML(strings.NewReader(page), "%zz"); err == nil || !strings.Contains(err.Error(), "invalid page URL") {
+		t.Errorf("expected invalid page URL error, got %v", err)
+	}
+}
+
+func TestExtractChannel(t *testing.T) {
+	ctx := context.Background()
+	file, err := os.Open(filepath.Join(testDataDir, "techcrunch.rss"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	channel, err := ParseRegular(ctx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mtx               sync.Mutex
+		active, maxActive int
+		requested         []string
+	)
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mtx.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		requested = append(requested, req.URL.String())
+		mtx.Unlock()
+		defer func() {
+			mtx.Lock()
+			active--
+			mtx.Unlock()
+		}()
+
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}
+		if strings.HasSuffix(req.URL.Path, "/") {
+			resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+			resp.Body = io.NopCloser(strings.NewReader(testArticlePage))
+		} else {
+			resp.Header.Set("Content-Type", "application/pdf")
+			resp.Body = io.NopCloser(strings.NewReader("%PDF"))
+		}
+		return resp, nil
+	})}
+
+	channel.Item[0].FullText = "<p>Already extracted</p>"
+	channel.Item[1].Link = "http://techcrunch.com/report.pdf"
+	extractor := &Extractor{Client: client, Concurrency: 2}
+	err = extractor.ExtractChannel(ctx, channel)
+	if err == nil || !strings.Contains(err.Error(), "report.pdf") || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Errorf("expected error for PDF link, got %v", err)
+	}
+	if len(requested) != len(channel.Item)-1 {
+		t.Errorf("expected %d requests, got %d", len(channel.Item)-1, len(requested))
+	}
+	if maxActive > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxActive)
+	}
+	if channel.Item[0].FullText != "<p>Already extracted</p>" {
+		t.Errorf("existing FullText was replaced")
+	}
+	if channel.Item[1].FullText != "" {
+		t.Errorf("FullText of failed item was set")
+	}
+	for _, item := range channel.Item[2:] {
+		if !strings.Contains(item.FullText, "The Berlin based startup") {
+			t.Errorf("FullText of %s not extracted: %q", item.Link, item.FullText)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package rss
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// atomNamespace is the namespace of Atom 1.0 documents
+	atomNamespace = "http://www.w3.org/2005/Atom"
+
+	// rdfNamespace is the namespace of the root element of RSS 1.0 documents
+	rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// ErrWrongFormat is wrapped by the errors of the parse functions if the root
+// element of the document does not belong to the expected feed format,
+// for example when an Atom feed is passed to ParseRegular.
+// Use errors.As with a *FormatError to get the found root element.
+var ErrWrongFormat = errors.New("wrong feed format")
+
+// ErrEmptyFeed is wrapped by the errors of the parse functions if a valid
+// feed has no items or entries and ParseOptions.RequireItems is set.
+var ErrEmptyFeed = errors.New("feed has no items")
+
+// FormatError is returned if the root element of a document
+// does not match the expected feed format.
+type FormatError struct {
+	// Expected is the format the parse function expected
+	Expected Format
+
+	// Root is the name of the root element that was found
+	Root xml.Name
+}
+
+// Error implements the error interface.
+func (e *FormatError) Error() string {
+	root := e.Root.Local
+	if e.Root.Space != "" {
+		root = e.Root.Space + " " + root
+	}
+	return fmt.Sprintf("expected %s feed, found root element <%s>", e.Expected, root)
+}
+
+// Is reports if target is ErrWrongFormat,
+// or ErrHTMLResponse if the root element is html.
+func (e *FormatError) Is(target error) bool {
+	return target == ErrWrongFormat || target == ErrHTMLResponse && strings.EqualFold(e.Root.Local, "html")
+}
+
+// rootStart returns the start element of the root of the document.
+func rootStart(xmlDecoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := xmlDecoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// isRegularRoot checks if name is the root element of an RSS 0.9x, 1.0 (RDF) or 2.0 document.
+func isRegularRoot(name xml.Name) bool {
+	return name.Local == "rss" || name.Local == "RDF" && name.Space == rdfNamespace
+}
+
+// isAtomRoot checks if name is the root element of an Atom document.
+// Documents without namespace are accepted, because some
+// feeds like the ones of Reddit omit it.
+func isAtomRoot(name xml.Name) bool {
+	return name.Local == "feed" && (name.Space == atomNamespace || name.Space == "")
+}
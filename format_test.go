@@ -0,0 +1,112 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseWrongFormat tests that documents of the wrong format are rejected
+func TestParseWrongFormat(t *testing.T) {
+	ctx := context.Background()
+
+	atomData, err := os.ReadFile(filepath.Join(testDataDir, "reddit-google.rss"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	_, err = ParseRegular(ctx, strings.NewReader(string(atomData)))
+	var formatErr *FormatError
+	if !errors.As(err, &formatErr) || !errors.Is(err, ErrWrongFormat) {
+		t.Fatalf("Expected FormatError wrapping ErrWrongFormat, got %v", err)
+	}
+	if formatErr.Expected != FormatRSS || formatErr.Root.Local != "feed" {
+		t.Errorf("Unexpected FormatError: %+v", formatErr)
+	}
+
+	rssData, err := os.ReadFile(filepath.Join(testDataDir, "wordpress.rss"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if _, err = ParseAtom(ctx, strings.NewReader(string(rssData))); !errors.Is(err, ErrWrongFormat) {
+		t.Errorf("Expected ErrWrongFormat for RSS passed to ParseAtom, got %v", err)
+	}
+
+	// A feed element in a foreign namespace is not Atom
+	foreign := `<feed xmlns="http://example.com/ns"><entry><id>1</id></entry></feed>`
+	if _, err = ParseAtom(ctx, strings.NewReader(foreign)); !errors.Is(err, ErrWrongFormat) {
+		t.Errorf("Expected ErrWrongFormat for foreign namespace, got %v", err)
+	}
+
+	html := `<html><body><h1>502 Bad Gateway</h1></body></html>`
+	_, err = ParseRegular(ctx, strings.NewReader(html))
+	if !errors.Is(err, ErrWrongFormat) || !errors.Is(err, ErrHTMLResponse) {
+		t.Errorf("Expected ErrWrongFormat and ErrHTMLResponse for HTML page, got %v", err)
+	}
+}
+
+// TestParseEmptyFeed tests that empty feeds are accepted unless ParseOptions.RequireItems is set
+func TestParseEmptyFeed(t *testing.T) {
+	ctx := context.Background()
+	rssData := `<rss version="2.0"><channel><title>Empty</title></channel></rss>`
+	atomData := `<feed xmlns="http://www.w3.org/2005/Atom"><title>Empty</title></feed>`
+	jsonData := `{"version": "https://jsonfeed.org/version/1.1", "title": "Empty", "items": []}`
+
+	channel, err := ParseRegular(ctx, strings.NewReader(rssData))
+	if err != nil || channel.Title != "Empty" {
+		t.Errorf("Expected empty channel, got %v, %v", channel, err)
+	}
+	feed, err := ParseAtom(ctx, strings.NewReader(atomData))
+	if err != nil || feed.Title != "Empty" {
+		t.Errorf("Expected empty feed, got %v, %v", feed, err)
+	}
+	parsed, err := ParseResponse(ctx, testResponse("application/feed+json", jsonData))
+	if err != nil || parsed.JSONFeed.Title != "Empty" {
+		t.Errorf("Expected empty JSON feed, got %v, %v", parsed, err)
+	}
+
+	options := ParseOptions{RequireItems: true}
+	if _, err := ParseRegularWithOptions(ctx, strings.NewReader(rssData), options); !errors.Is(err, ErrEmptyFeed) {
+		t.Errorf("Expected ErrEmptyFeed, got %v", err)
+	}
+	if _, err := ParseAtomWithOptions(ctx, strings.NewReader(atomData), options); !errors.Is(err, ErrEmptyFeed) {
+		t.Errorf("Expected ErrEmptyFeed, got %v", err)
+	}
+	for _, data := range []string{rssData, atomData, jsonData} {
+		if _, err := ParseResponseWithOptions(ctx, testResponse("", data), options); !errors.Is(err, ErrEmptyFeed) {
+			t.Errorf("Expected ErrEmptyFeed, got %v", err)
+		}
+	}
+	if _, err := ParseResponseWithOptions(ctx, testResponse("", testJSONFeed), options); err != nil {
+		t.Errorf("Expected feed with items to be accepted, got %v", err)
+	}
+}
+
+// TestParseRegularRDF tests parsing of RSS 1.0 documents with items outside the channel
+func TestParseRegularRDF(t *testing.T) {
+	rdfData := `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+	<channel rdf:about="http://example.com/">
+		<title>RDF Channel</title>
+		<link>http://example.com/</link>
+	</channel>
+	<item rdf:about="http://example.com/1">
+		<title>First</title>
+		<link>http://example.com/1</link>
+	</item>
+	<item rdf:about="http://example.com/2">
+		<title>Second</title>
+		<link>http://example.com/2</link>
+	</item>
+</rdf:RDF>`
+
+	channel, err := ParseRegular(context.Background(), strings.NewReader(rdfData))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	if channel.Title != "RDF Channel" || len(channel.Item) != 2 || channel.Item[1].Link != "http://example.com/2" {
+		t.Errorf("Unexpected channel: %+v", channel)
+	}
+}
@@ -1,5 +1,8 @@
 module github.com/ungerik/go-rss
 
-go 1.23
+go 1.23.0
 
-require github.com/paulrosania/go-charset v0.0.0-20190326053356-55c9d7a5834c
+require (
+	golang.org/x/net v0.42.0
+	golang.org/x/text v0.27.0
+)
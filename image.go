@@ -0,0 +1,385 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	htmlparser "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minImageSize is the minimum width and height in pixels of representative images.
+// Smaller images are usually icons, avatars or tracking pixels.
+const minImageSize = 100
+
+// mediaTypes are the MIME types of common media file extensions,
+// used if the extension is not known to the mime package.
+var mediaTypes = map[string]string{
+	".aac": "audio/aac", ".avif": "image/avif", ".flac": "audio/flac", ".gif": "image/gif",
+	".jpeg": "image/jpeg", ".jpg": "image/jpeg", ".m4a": "audio/mp4", ".m4v": "video/x-m4v",
+	".mov": "video/quicktime", ".mp3": "audio/mpeg", ".mp4": "video/mp4", ".oga": "audio/ogg",
+	".ogg": "audio/ogg", ".ogv": "video/ogg", ".opus": "audio/opus", ".pdf": "application/pdf",
+	".png": "image/png", ".svg": "image/svg+xml", ".wav": "audio/wav", ".webm": "video/webm",
+	".webp": "image/webp",
+}
+
+// MediaContent represents a media:content element of an RSS item (Media RSS).
+type MediaContent struct {
+	// URL is the location of the media object
+	URL string `xml:"url,attr" json:"url,omitempty"`
+
+	// Type is the MIME type of the media object
+	Type string `xml:"type,attr,omitempty" json:"type,omitempty"`
+
+	// Medium is the kind of media object: image, audio, video, document or executable
+	Medium string `xml:"medium,attr,omitempty" json:"medium,omitempty"`
+
+	// FileSize is the size of the media object in bytes as found in the feed
+	FileSize string `xml:"fileSize,attr,omitempty" json:"fileSize,omitempty"`
+
+	// Width is the width of the media object in pixels as found in the feed
+	Width string `xml:"width,attr,omitempty" json:"width,omitempty"`
+
+	// Height is the height of the media object in pixels as found in the feed
+	Height string `xml:"height,attr,omitempty" json:"height,omitempty"`
+
+	// Title is the title of the media object
+	Title string `xml:"http://search.yahoo.com/mrss/ title,omitempty" json:"title,omitempty"`
+}
+
+// MediaThumbnail represents a media:thumbnail element of an RSS item (Media RSS).
+type MediaThumbnail struct {
+	// URL is the location of the thumbnail image
+	URL string `xml:"url,attr" json:"url,omitempty"`
+
+	// Width is the width of the image in pixels as found in the feed
+	Width string `xml:"width,attr,omitempty" json:"width,omitempty"`
+
+	// Height is the height of the image in pixels as found in the feed
+	Height string `xml:"height,attr,omitempty" json:"height,omitempty"`
+}
+
+// ITunesImage represents an itunes:image element of a podcast or episode.
+type ITunesImage struct {
+	// Href is the URL of the artwork
+	Href string `xml:"href,attr" json:"href,omitempty"`
+}
+
+// ChannelImage represents the image element of an RSS channel.
+type ChannelImage struct {
+	// URL is the location of the image
+	URL string `xml:"url" json:"url,omitempty"`
+
+	// Title describes the image
+	Title string `xml:"title,omitempty" json:"title,omitempty"`
+
+	// Link is the URL of the website of the channel
+	Link string `xml:"link,omitempty" json:"link,omitempty"`
+
+	// Width is the width of the image in pixels as found in the feed
+	Width string `xml:"width,omitempty" json:"width,omitempty"`
+
+	// Height is the height of the image in pixels as found in the feed
+	Height string `xml:"height,omitempty" json:"height,omitempty"`
+}
+
+// Enclosure is a media file of an item or entry with parsed
+// length and MIME type, see Item.Enclosures.
+type Enclosure struct {
+	// URL is the location of the file
+	URL string
+
+	// Type is the lowercase MIME type without parameters like "audio/mpeg".
+	// If the feed declares no type, it is guessed from the file extension of the URL.
+	// Empty if unknown.
+	Type string
+
+	// Length is the size of the file in bytes, 0 if unknown or invalid
+	Length int64
+}
+
+// Medium returns the top-level MIME type of the enclosure
+// like "image", "audio" or "video", or an empty string if unknown.
+func (e *Enclosure) Medium() string {
+	medium, _, _ := strings.Cut(e.Type, "/")
+	return medium
+}
+
+// Enclosures returns the enclosures of the item followed by its media:content
+// elements with other URLs, with parsed length and MIME type.
+func (item *Item) Enclosures() []Enclosure {
+	var enclosures []Enclosure
+	seen := make(map[string]bool)
+	add := func(rawURL, mimeType, medium, length string) {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" || seen[rawURL] {
+			return
+		}
+		seen[rawURL] = true
+		enclosures = append(enclosures, newEnclosure(rawURL, mimeType, medium, length))
+	}
+	for _, enclosure := range item.Enclosure {
+		add(enclosure.URL, enclosure.Type, "", enclosure.Length)
+	}
+	for _, media := range item.Media {
+		add(media.URL, media.Type, media.Medium, media.FileSize)
+	}
+	return enclosures
+}
+
+// Enclosures returns the links of the entry with the relation "enclosure"
+// with parsed length and MIME type.
+func (e *Entry) Enclosures() []Enclosure {
+	var enclosures []Enclosure
+	for _, link := range enclosureLinks(e.Link) {
+		if href := strings.TrimSpace(link.Href); href != "" {
+			enclosures = append(enclosures, newEnclosure(href, link.Type, "", link.Length))
+		}
+	}
+	return enclosures
+}
+
+// newEnclosure returns an Enclosure with the parsed MIME type and length.
+// The medium of Media RSS is used if the type can't be determined otherwise.
+func newEnclosure(rawURL, mimeType, medium, length string) Enclosure {
+	enclosure := Enclosure{URL: rawURL}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		enclosure.Type = mediaType
+	} else {
+		enclosure.Type = guessMediaType(rawURL)
+	}
+	if enclosure.Type == "" && medium != "" {
+		enclosure.Type = strings.ToLower(strings.TrimSpace(medium)) + "/*"
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(length), 10, 64); err == nil && n > 0 {
+		enclosure.Length = n
+	}
+	return enclosure
+}
+
+// guessMediaType returns the MIME type of the file extension of the URL
+// without parameters, or an empty string if unknown.
+func guessMediaType(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if mediaType, ok := mediaTypes[ext]; ok {
+		return mediaType
+	}
+	if ext == "" {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext))
+	return mediaType
+}
+
+// Image returns the URL of a representative image of the item for thumbnails,
+// or an empty string if the item has none. The first found of these is used:
+//
+//  1. a media:content image, then a media:thumbnail
+//  2. an enclosure with an image MIME type
+//  3. the itunes:image of the episode
+//  4. the first img element of the full text, content or description
+//     that is not smaller than 100 pixels and not a tracking pixel,
+//     preferring the data-src attribute of lazy loaded images
+//
+// Use Channel.ItemImage to fall back to the image of the channel
+// and DiscoverImage for the Open Graph image of the item's web page.
+func (item *Item) Image() string {
+	for _, media := range item.Media {
+		enclosure := newEnclosure(strings.TrimSpace(media.URL), media.Type, media.Medium, "")
+		if enclosure.URL != "" && enclosure.Medium() == "image" && sizable(media.Width, media.Height) {
+			return enclosure.URL
+		}
+	}
+	for _, thumbnail := range item.MediaThumbnail {
+		if thumbnailURL := strings.TrimSpace(thumbnail.URL); thumbnailURL != "" && sizable(thumbnail.Width, thumbnail.Height) {
+			return thumbnailURL
+		}
+	}
+	for _, enclosure := range item.Enclosures() {
+		if enclosure.Medium() == "image" {
+			return enclosure.URL
+		}
+	}
+	if item.ITunesImage != nil && strings.TrimSpace(item.ITunesImage.Href) != "" {
+		return strings.TrimSpace(item.ITunesImage.Href)
+	}
+	for _, content := range []string{item.FullText, item.Content, item.Description} {
+		if image := firstImage(content); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// ItemImage returns the representative image of the item, see Item.Image,
+// falling back to the itunes:image of the channel and then its image element.
+func (c *Channel) ItemImage(item *Item) string {
+	if image := item.Image(); image != "" {
+		return image
+	}
+	if c.ITunesImage != nil && strings.TrimSpace(c.ITunesImage.Href) != "" {
+		return strings.TrimSpace(c.ITunesImage.Href)
+	}
+	if c.Image != nil {
+		return strings.TrimSpace(c.Image.URL)
+	}
+	return ""
+}
+
+// Image returns the URL of a representative image of the entry, the first
+// enclosure link with an image MIME type or the first sizable img element of
+// the content or summary, see Item.Image. Returns an empty string if the entry has none.
+func (e *Entry) Image() string {
+	for _, enclosure := range e.Enclosures() {
+		if enclosure.Medium() == "image" {
+			return enclosure.URL
+		}
+	}
+	for _, content := range []*Content{&e.Content, &e.Summary} {
+		if image := firstImage(content.HTML()); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// firstImage returns the src of the first img element of the HTML content
+// that is sizable and not a tracking pixel.
+func firstImage(content string) string {
+	if !strings.Contains(content, "<img") && !strings.Contains(content, "<IMG") {
+		return ""
+	}
+	body := &htmlparser.Node{Type: htmlparser.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := htmlparser.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return ""
+	}
+	policy := DefaultSanitizePolicy()
+	var find func(*htmlparser.Node) string
+	find = func(node *htmlparser.Node) string {
+		if node.Type == htmlparser.ElementNode && node.Data == "img" {
+			src := strings.TrimSpace(firstNonEmpty(nodeAttr(node, "data-src"), nodeAttr(node, "src")))
+			if src != "" && !strings.HasPrefix(src, "data:") &&
+				!policy.isTrackingPixel(node) && sizable(nodeAttr(node, "width"), nodeAttr(node, "height")) {
+				return src
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if src := find(child); src != "" {
+				return src
+			}
+		}
+		return ""
+	}
+	for _, node := range nodes {
+		if src := find(node); src != "" {
+			return src
+		}
+	}
+	return ""
+}
+
+// sizable reports if the width and height are unknown or at least minImageSize.
+func sizable(width, height string) bool {
+	for _, dimension := range []string{width, height} {
+		value := strings.TrimSuffix(strings.TrimSpace(dimension), "px")
+		if size, err := strconv.Atoi(value); err == nil && size < minImageSize {
+			return false
+		}
+	}
+	return true
+}
+
+// openGraphImageProperties are the meta properties of images of web pages
+// in order of precedence.
+var openGraphImageProperties = []string{
+	"og:image:secure_url",
+	"og:image",
+	"og:image:url",
+	"twitter:image",
+	"twitter:image:src",
+}
+
+// DiscoverImage fetches the web page at pageURL, usually the link of an item,
+// and returns the URL of its Open Graph image (og:image) or Twitter card image.
+//
+// Returns an empty string if the page declares no image.
+// A nil client means http.DefaultClient.
+func DiscoverImage(ctx context.Context, pageURL string, client *http.Client) (string, error) {
+	req, err := newRequest(ctx, pageURL, false)
+	if err != nil {
+		return "", err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := do(client, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Resolve against the final URL after redirects
+	finalURL := req.URL
+	if resp.Request != nil {
+		finalURL = resp.Request.URL
+	}
+	image, err := openGraphImage(io.LimitReader(resp.Body, 1<<20), finalURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to read page: %w", err)
+	}
+	return image, nil
+}
+
+// openGraphImage returns the image declared by the meta elements
+// in the head of an HTML page, resolved against pageURL.
+func openGraphImage(r io.Reader, pageURL *url.URL) (string, error) {
+	images := make(map[string]string)
+	tokenizer := htmlparser.NewTokenizer(r)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == htmlparser.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", err
+			}
+			break
+		}
+		if tokenType != htmlparser.StartTagToken && tokenType != htmlparser.SelfClosingTagToken {
+			if tokenType == htmlparser.EndTagToken {
+				if name, _ := tokenizer.TagName(); string(name) == "head" {
+					break
+				}
+			}
+			continue
+		}
+		token := tokenizer.Token()
+		if token.Data == "body" {
+			break
+		}
+		if token.Data != "meta" {
+			continue
+		}
+		// Open Graph uses property, Twitter cards use name
+		property := strings.ToLower(firstNonEmpty(tokenAttr(token, "property"), tokenAttr(token, "name")))
+		if content := strings.TrimSpace(tokenAttr(token, "content")); content != "" && images[property] == "" {
+			images[property] = content
+		}
+	}
+	for _, property := range openGraphImageProperties {
+		if image := images[property]; image != "" {
+			return resolveURL(pageURL, image), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,224 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseTestChannel(t *testing.T, name string) *Channel {
+	t.Helper()
+	file, err := os.Open(filepath.Join(testDataDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	channel, err := ParseRegular(context.Background(), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return channel
+}
+
+func TestItemImageMedia(t *testing.T) {
+	channel := parseTestChannel(t, "techcrunch.rss")
+	item := &channel.Item[0]
+	if len(item.Media) != 3 || len(item.MediaThumbnail) != 1 {
+		t.Fatalf("expected 3 media contents and 1 thumbnail, got %d and %d", len(item.Media), len(item.MediaThumbnail))
+	}
+	if item.Media[0].Title != "WePopp%20Adds%20Restaurant%20Booking%20Via%26nbsp%3BOpenTable" {
+		t.Errorf("unexpected media title %q", item.Media[0].Title)
+	}
+	if strings.Contains(item.Content, "WePopp") {
+		t.Errorf("media:content was decoded as content: %q", item.Content)
+	}
+	want := "http://tctechcrunch2011.files.wordpress.com/2014/02/unnamed-9.png"
+	if got := item.Image(); got != want {
+		t.Errorf("Image() = %q, want %q", got, want)
+	}
+
+	// Media RSS survives writing
+	var buf bytes.Buffer
+	if err := WriteRegular(&buf, channel); err != nil {
+		t.Fatal(err)
+	}
+	written, err := ParseRegular(context.Background(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := written.Item[0].Image(); got != want {
+		t.Errorf("Image() after writing = %q, want %q", got, want)
+	}
+}
+
+func TestItemImageFallbacks(t *testing.T) {
+	tests := []struct {
+		name string
+		item Item
+		want string
+	}{
+		{
+			name: "small media is skipped",
+			item: Item{
+				Media:          []MediaContent{{URL: "https://example.com/icon.png", Medium: "image", Width: "16"}},
+				MediaThumbnail: []MediaThumbnail{{URL: "https://example.com/thumb.jpg"}},
+			},
+			want: "https://example.com/thumb.jpg",
+		},
+		{
+			name: "image enclosure",
+			item: Item{Enclosure: []ItemEnclosure{
+				{URL: "https://example.com/episode.mp3", Type: "audio/mpeg"},
+				{URL: "https://example.com/cover.JPG"},
+			}},
+			want: "https://example.com/cover.JPG",
+		},
+		{
+			name: "itunes image",
+			item: Item{ITunesImage: &ITunesImage{Href: "https://example.com/episode.jpg"}},
+			want: "https://example.com/episode.jpg",
+		},
+		{
+			name: "sizable img in description",
+			item: Item{Description: `<p><img src="https://feeds.feedburner.com/~r/x.gif"><img src="/avatar.png" width="48" height="48">` +
+				`<img src="https://example.com/photo.jpg" width="640"></p>`},
+			want: "https://example.com/photo.jpg",
+		},
+		{
+			name: "lazy loaded img",
+			item: Item{Content: `<img src="data:image/gif;base64,R0lGOD" data-src="https://example.com/lazy.jpg">`},
+			want: "https://example.com/lazy.jpg",
+		},
+		{
+			name: "no image",
+			item: Item{Description: "<p>Text only</p>"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.Image(); got != tt.want {
+				t.Errorf("Image() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelItemImage(t *testing.T) {
+	channel := parseTestChannel(t, "podcast.rss")
+	if channel.Image == nil || channel.Image.URL != "http://podcast.wandwmusic.nl/audio/rssimage.jpg" {
+		t.Errorf("unexpected channel image %+v", channel.Image)
+	}
+	want := "http://podcast.wandwmusic.nl/audio/itunescover.jpg"
+	if got := channel.ItemImage(&channel.Item[0]); got != want {
+		t.Errorf("ItemImage() = %q, want %q", got, want)
+	}
+	channel.ITunesImage = nil
+	if got := channel.ItemImage(&channel.Item[0]); got != channel.Image.URL {
+		t.Errorf("ItemImage() = %q, want channel image", got)
+	}
+}
+
+func TestItemEnclosures(t *testing.T) {
+	channel := parseTestChannel(t, "podcast.rss")
+	enclosures := channel.Item[0].Enclosures()
+	if len(enclosures) != 1 {
+		t.Fatalf("expected repeated enclosures once, got %d", len(enclosures))
+	}
+	want := Enclosure{
+		URL:    "http://podcast.wandwmusic.nl/pod/wandw_mainstage_podcast-2015-05-30-50305.m4a",
+		Type:   "application/octet-stream",
+		Length: 63963136,
+	}
+	if enclosures[0] != want {
+		t.Errorf("Enclosures() = %+v, want %+v", enclosures[0], want)
+	}
+
+	item := Item{
+		Enclosure: []ItemEnclosure{{URL: "https://example.com/a.mp3", Length: "invalid"}},
+		Media: []MediaContent{
+			{URL: "https://example.com/a.mp3"},
+			{URL: "https://example.com/video", Medium: "video", FileSize: "1024"},
+			{URL: "https://example.com/b.ogg", Type: "Audio/Ogg; codecs=opus"},
+		},
+	}
+	got := item.Enclosures()
+	wantAll := []Enclosure{
+		{URL: "https://example.com/a.mp3", Type: "audio/mpeg"},
+		{URL: "https://example.com/video", Type: "video/*", Length: 1024},
+		{URL: "https://example.com/b.ogg", Type: "audio/ogg"},
+	}
+	if len(got) != len(wantAll) {
+		t.Fatalf("Enclosures() = %+v", got)
+	}
+	for i := range wantAll {
+		if got[i] != wantAll[i] {
+			t.Errorf("Enclosures()[%d] = %+v, want %+v", i, got[i], wantAll[i])
+		}
+	}
+	if medium := got[1].Medium(); medium != "video" {
+		t.Errorf("Medium() = %q", medium)
+	}
+}
+
+func TestEntryImage(t *testing.T) {
+	entry := Entry{
+		Link: []Link{
+			{Href: "https://example.com/post"},
+			{Href: "https://example.com/podcast.mp3", Rel: "enclosure", Type: "audio/mpeg", Length: "2048"},
+		},
+		Content: Content{Type: "html", Body: `<p><img src="/images/photo.jpg"></p>`, XMLBase: "https://example.com/"},
+	}
+	enclosures := entry.Enclosures()
+	if len(enclosures) != 1 || enclosures[0].Length != 2048 || enclosures[0].Type != "audio/mpeg" {
+		t.Errorf("Enclosures() = %+v", enclosures)
+	}
+	if got := entry.Image(); got != "https://example.com/images/photo.jpg" {
+		t.Errorf("Image() = %q", got)
+	}
+}
+
+func TestDiscoverImage(t *testing.T) {
+	page := `<!DOCTYPE html><html><head>
+		<meta name="twitter:image" content="https://example.com/twitter.jpg">
+		<meta property="og:image" content="/images/og.jpg">
+		</head><body><meta property="og:image" content="https://example.com/body.jpg"></body></html>`
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/html"}},
+			Body:       io.NopCloser(strings.NewReader(page)),
+			Request:    req,
+		}, nil
+	})}
+	image, err := DiscoverImage(context.Background(), "https://example.com/post/1", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != "https://example.com/images/og.jpg" {
+		t.Errorf("DiscoverImage() = %q", image)
+	}
+}
+
+func TestDiscoverImageDefaultClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><meta property="og:image" content="/og.jpg"></head></html>`))
+	}))
+	defer server.Close()
+
+	// A nil client uses http.DefaultClient
+	image, err := DiscoverImage(context.Background(), server.URL+"/post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != server.URL+"/og.jpg" {
+		t.Errorf("DiscoverImage() = %q", image)
+	}
+}
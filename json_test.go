@@ -0,0 +1,109 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestChannelJSON tests the JSON representation of RSS channels
+func TestChannelJSON(t *testing.T) {
+	file, err := os.Open(filepath.Join(testDataDir, "podcast.rss"))
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+	channel, err := ParseRegular(context.Background(), file)
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+
+	data, err := json.Marshal(channel)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	for _, expected := range []string{`"link":"http://`, `"items":[`, `"pubDate":"2015-05-30T11:11:00Z"`, `"enclosures":[{"url":`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected JSON to contain %s", expected)
+		}
+	}
+	for _, unexpected := range []string{`"Title"`, `"comments"`, `"fullText"`, `"xmlBase"`} {
+		if strings.Contains(string(data), unexpected) {
+			t.Errorf("Expected JSON not to contain %s", unexpected)
+		}
+	}
+
+	var decoded Channel
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if len(decoded.Item) != len(channel.Item) || decoded.Item[0].Title != channel.Item[0].Title {
+		t.Fatalf("Unexpected decoded channel with %d items", len(decoded.Item))
+	}
+	if pubDate, err := decoded.Item[0].PubDate.Parse(); err != nil || pubDate.Unix() != 1432984260 {
+		t.Errorf("Expected decoded date to parse, got %v, %v", pubDate, err)
+	}
+	again, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Error("Expected stable JSON after round trip")
+	}
+}
+
+// TestFeedJSON tests the JSON representation of Atom feeds
+func TestFeedJSON(t *testing.T) {
+	atomData := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:feed</id>
+	<title>Feed</title>
+	<updated>2024-01-01T12:00:00Z</updated>
+	<entry>
+		<id>urn:1</id>
+		<title>With summary</title>
+		<updated>2024-01-01T12:00:00Z</updated>
+		<author><name>Jane</name></author>
+		<summary type="html">&lt;p&gt;Summary&lt;/p&gt;</summary>
+	</entry>
+	<entry>
+		<id>urn:2</id>
+		<title>Without summary</title>
+		<updated>2024-01-02T12:00:00Z</updated>
+		<link href="https://example.com/2"/>
+	</entry>
+</feed>`
+	feed, err := ParseAtom(context.Background(), strings.NewReader(atomData))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+
+	data, err := json.Marshal(feed)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	expected := `{"id":"urn:feed","title":"Feed","updated":"2024-01-01T12:00:00Z","entries":[` +
+		`{"id":"urn:1","title":"With summary","updated":"2024-01-01T12:00:00Z","authors":[{"name":"Jane"}],"summary":{"type":"html","body":"\u003cp\u003eSummary\u003c/p\u003e"}},` +
+		`{"id":"urn:2","title":"Without summary","updated":"2024-01-02T12:00:00Z","links":[{"href":"https://example.com/2"}]}]}`
+	if string(data) != expected {
+		t.Errorf("Expected JSON\n%s\ngot\n%s", expected, data)
+	}
+
+	var decoded Feed
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if decoded.Entry[0].Summary.Body != "<p>Summary</p>" || decoded.Entry[1].URL() != "https://example.com/2" {
+		t.Errorf("Unexpected decoded feed: %+v", decoded)
+	}
+	again, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("Expected stable JSON after round trip, got\n%s", again)
+	}
+}
@@ -0,0 +1,174 @@
+package rss
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonFeedVersionPrefix is the prefix of the version URL of all JSON Feed versions.
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+// JSONFeed represents a JSON Feed document.
+// It follows the JSON Feed 1.1 specification (https://jsonfeed.org/version/1.1)
+// and also accepts version 1.0 documents.
+type JSONFeed struct {
+	// Version is the URL of the JSON Feed version, like "https://jsonfeed.org/version/1.1"
+	Version string `json:"version"`
+
+	// Title is the name of the feed
+	Title string `json:"title"`
+
+	// HomePageURL is the URL of the website the feed describes
+	HomePageURL string `json:"home_page_url,omitempty"`
+
+	// FeedURL is the URL of the feed itself
+	FeedURL string `json:"feed_url,omitempty"`
+
+	// Description describes the feed
+	Description string `json:"description,omitempty"`
+
+	// Icon is the URL of an image for the feed, suitable for large displays
+	Icon string `json:"icon,omitempty"`
+
+	// Favicon is the URL of a small image for the feed
+	Favicon string `json:"favicon,omitempty"`
+
+	// Authors is a list of authors of the feed
+	Authors []JSONFeedAuthor `json:"authors,omitempty"`
+
+	// Language is the primary language of the feed, like "en-US"
+	Language string `json:"language,omitempty"`
+
+	// Items is a slice of items in the feed
+	Items []JSONFeedItem `json:"items"`
+}
+
+// JSONFeedItem represents a single item in a JSON Feed.
+type JSONFeedItem struct {
+	// ID uniquely identifies the item
+	ID string `json:"id"`
+
+	// URL is the URL of the item's web page
+	URL string `json:"url,omitempty"`
+
+	// ExternalURL is the URL of a page the item is about
+	ExternalURL string `json:"external_url,omitempty"`
+
+	// Title is the title of the item
+	Title string `json:"title,omitempty"`
+
+	// ContentHTML is the HTML content of the item
+	ContentHTML string `json:"content_html,omitempty"`
+
+	// ContentText is the plain text content of the item
+	ContentText string `json:"content_text,omitempty"`
+
+	// Summary is a plain text summary of the item
+	Summary string `json:"summary,omitempty"`
+
+	// Image is the URL of the main image of the item
+	Image string `json:"image,omitempty"`
+
+	// DatePublished is the publication date of the item in RFC 3339 format
+	DatePublished string `json:"date_published,omitempty"`
+
+	// DateModified is the modification date of the item in RFC 3339 format
+	DateModified string `json:"date_modified,omitempty"`
+
+	// Authors is a list of authors of the item
+	Authors []JSONFeedAuthor `json:"authors,omitempty"`
+
+	// Tags is a list of tags of the item
+	Tags []string `json:"tags,omitempty"`
+
+	// Attachments is a list of media files associated with the item
+	Attachments []JSONFeedAttachment `json:"attachments,omitempty"`
+}
+
+// JSONFeedAuthor represents the author of a JSON Feed or item.
+type JSONFeedAuthor struct {
+	// Name is the name of the author
+	Name string `json:"name,omitempty"`
+
+	// URL is the URL of the author's website
+	URL string `json:"url,omitempty"`
+
+	// Avatar is the URL of an image of the author
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// JSONFeedAttachment represents a media file attached to a JSON Feed item.
+type JSONFeedAttachment struct {
+	// URL is the location of the attached file
+	URL string `json:"url"`
+
+	// MIMEType is the MIME type of the attached file
+	MIMEType string `json:"mime_type"`
+
+	// Title is the name of the attachment
+	Title string `json:"title,omitempty"`
+
+	// SizeInBytes is the size of the attached file
+	SizeInBytes int64 `json:"size_in_bytes,omitempty"`
+
+	// DurationInSeconds is the duration of audio or video attachments
+	DurationInSeconds float64 `json:"duration_in_seconds,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The id of items may be a number in feeds created by some generators,
+// it is converted to a string.
+func (item *JSONFeedItem) UnmarshalJSON(data []byte) error {
+	type plainItem JSONFeedItem
+	var raw struct {
+		plainItem
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*item = JSONFeedItem(raw.plainItem)
+	item.ID = jsonString(raw.ID)
+	return nil
+}
+
+// jsonString returns a JSON string value unquoted
+// and other values like numbers as they are.
+func jsonString(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	if string(value) == "null" {
+		return ""
+	}
+	return string(value)
+}
+
+// ParseJSONFeed parses a JSON Feed from an io.Reader.
+// It expects the reader to contain a JSON Feed document with a version
+// starting with "https://jsonfeed.org/version/".
+// The context is used for cancellation control during parsing.
+//
+// Returns a JSONFeed struct containing the parsed data and any error that occurred.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func ParseJSONFeed(ctx context.Context, r io.Reader) (*JSONFeed, error) {
+	// Check if context is cancelled before starting
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var feed JSONFeed
+	if err := json.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(feed.Version, jsonFeedVersionPrefix) {
+		return nil, fmt.Errorf("unsupported JSON Feed version %q", feed.Version)
+	}
+	return &feed, nil
+}
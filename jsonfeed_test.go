@@ -0,0 +1,33 @@
+package rss
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestParseJSONFeed tests parsing of a JSON Feed document
+func TestParseJSONFeed(t *testing.T) {
+	feed, err := ParseJSONFeed(context.Background(), strings.NewReader(testJSONFeed))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed failed: %v", err)
+	}
+	if feed.Title != "JSON Example" || feed.HomePageURL != "https://example.com/" {
+		t.Errorf("Unexpected feed metadata: %+v", feed)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(feed.Items))
+	}
+	// Numeric ids are converted to strings
+	if feed.Items[0].ID != "1" || feed.Items[1].ID != "2" {
+		t.Errorf("Expected ids 1 and 2, got %q and %q", feed.Items[0].ID, feed.Items[1].ID)
+	}
+	if feed.Items[0].ContentHTML != "<p>Hello</p>" || feed.Items[1].Tags[0] != "go" {
+		t.Errorf("Unexpected items: %+v", feed.Items)
+	}
+
+	_, err = ParseJSONFeed(context.Background(), strings.NewReader(`{"version": "1", "items": []}`))
+	if err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+}
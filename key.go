@@ -0,0 +1,139 @@
+package rss
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KeyOptions configures the computation of item and entry keys.
+// The zero value is the default used by Item.Key and Entry.Key.
+type KeyOptions struct {
+	// IgnoreGUID skips the GUID of items and the ID of entries,
+	// for feeds that reuse them for different items or change them on edits
+	IgnoreGUID bool
+
+	// NormalizeURLs normalizes links before using them as key, so that
+	// variants of the same URL result in the same key: the scheme, fragment,
+	// default ports, tracking parameters like utm_source and trailing slashes
+	// are removed, the host is lowercased and query parameters are sorted
+	NormalizeURLs bool
+}
+
+// Key returns a stable identity of the item, used by Channel.Dedupe,
+// MergeRegular and UnseenItems. It is the first non-empty of:
+//
+//  1. the GUID
+//  2. the link
+//  3. a hash of the title, the publication date and the first enclosure URL,
+//     prefixed with "sha256:". A missing or invalid date is hashed as found
+//     in the feed, not as ParseOptions.DefaultDate
+func (item *Item) Key() string {
+	return item.KeyWithOptions(KeyOptions{})
+}
+
+// KeyWithOptions returns the key of the item like Key using the options.
+func (item *Item) KeyWithOptions(options KeyOptions) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" && !options.IgnoreGUID {
+		return guid
+	}
+	if link := keyURL(item.Link, options); link != "" {
+		return link
+	}
+	var enclosure string
+	if len(item.Enclosure) > 0 {
+		enclosure = keyURL(item.Enclosure[0].URL, options)
+	}
+	return contentHash(item.Title, keyDate(keyTime(item.PubTime, string(item.PubDate)), string(item.PubDate)), enclosure)
+}
+
+// Key returns a stable identity of the entry, used by Feed.Dedupe,
+// MergeAtom and UnseenEntries. It is the first non-empty of:
+//
+//  1. the ID
+//  2. the URL of the alternate link
+//  3. a hash of the title, the published date and the first enclosure link,
+//     prefixed with "sha256:". The updated date is not part of the hash,
+//     so that edits of the entry don't change its key
+func (e *Entry) Key() string {
+	return e.KeyWithOptions(KeyOptions{})
+}
+
+// KeyWithOptions returns the key of the entry like Key using the options.
+func (e *Entry) KeyWithOptions(options KeyOptions) string {
+	if id := strings.TrimSpace(e.ID); id != "" && !options.IgnoreGUID {
+		return id
+	}
+	if link := keyURL(e.URL(), options); link != "" {
+		return link
+	}
+	var enclosure string
+	for _, link := range e.Link {
+		if link.Rel == "enclosure" {
+			enclosure = keyURL(link.Href, options)
+			break
+		}
+	}
+	return contentHash(e.Title, keyDate(keyTime(e.PublishedTime, e.Published), e.Published), enclosure)
+}
+
+// ItemKeyFunc returns a function computing the keys of items with the options,
+// usable as key of Channel.Dedupe, DiffRegular, UnseenItems and MergeOptions.ItemKey.
+func ItemKeyFunc(options KeyOptions) func(item *Item) string {
+	return func(item *Item) string {
+		return item.KeyWithOptions(options)
+	}
+}
+
+// EntryKeyFunc returns a function computing the keys of entries with the options,
+// usable as key of Feed.Dedupe, DiffAtom, UnseenEntries and MergeOptions.EntryKey.
+func EntryKeyFunc(options KeyOptions) func(entry *Entry) string {
+	return func(entry *Entry) string {
+		return entry.KeyWithOptions(options)
+	}
+}
+
+// keyTime returns the resolved time if it was parsed from the raw date,
+// or parses the raw date if it was not resolved. ParseOptions.DefaultDate
+// of missing and invalid dates is ignored, so that it doesn't change keys.
+func keyTime(dt DateTime, raw string) time.Time {
+	if dt.Raw != "" {
+		if dt.Invalid {
+			return time.Time{}
+		}
+		return dt.Time
+	}
+	t, _ := Date(raw).Parse()
+	return t
+}
+
+// keyDate returns the time in RFC 3339 UTC, so that the same time in
+// different formats results in the same key, or the raw date if the time is zero.
+func keyDate(t time.Time, raw string) string {
+	if t.IsZero() {
+		return strings.TrimSpace(raw)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// keyURL returns the trimmed URL, normalized if configured by the options.
+func keyURL(rawURL string, options KeyOptions) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if !options.NormalizeURLs || rawURL == "" {
+		return rawURL
+	}
+	u, err := url.Parse(StripTrackingParams(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	key := host + path
+	if u.RawQuery != "" {
+		key += "?" + u.Query().Encode()
+	}
+	return key
+}
@@ -0,0 +1,191 @@
+package rss
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestItemKey(t *testing.T) {
+	enclosure := []ItemEnclosure{{URL: "https://example.com/episode.mp3"}}
+	tests := []struct {
+		name string
+		item Item
+		want string
+	}{
+		{name: "guid", item: Item{GUID: " guid-1 ", Link: "https://example.com/1"}, want: "guid-1"},
+		{name: "link", item: Item{Link: "https://example.com/1", Title: "One"}, want: "https://example.com/1"},
+		{name: "hash", item: Item{Title: "One", Enclosure: enclosure}, want: "sha256:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.item.Key()
+			if tt.want == "sha256:" {
+				if !strings.HasPrefix(got, tt.want) {
+					t.Errorf("Key() = %q, want hash", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemKeyHash(t *testing.T) {
+	parse := func(pubDate string) Item {
+		item := Item{Title: "Episode", PubDate: Date(pubDate), Enclosure: []ItemEnclosure{{URL: "https://example.com/1.mp3"}}}
+		item.PubTime = newDateTime(pubDate, ParseOptions{})
+		return item
+	}
+	a := parse("Tue, 05 Mar 2024 12:00:00 +0000")
+	b := parse("Tue, 05 Mar 2024 13:00:00 +0100")
+	if a.Key() != b.Key() {
+		t.Errorf("same time in different zones: %q != %q", a.Key(), b.Key())
+	}
+	c := parse("Wed, 06 Mar 2024 12:00:00 +0000")
+	if a.Key() == c.Key() {
+		t.Error("different dates must give different keys")
+	}
+	d := a
+	d.Enclosure = []ItemEnclosure{{URL: "https://example.com/2.mp3"}}
+	if a.Key() == d.Key() {
+		t.Error("different enclosures must give different keys")
+	}
+}
+
+func TestItemKeyWithOptions(t *testing.T) {
+	item := Item{GUID: "42", Link: "http://Example.com:80/post/?utm_source=feed&b=2&a=1#comments"}
+	if got := item.KeyWithOptions(KeyOptions{NormalizeURLs: true}); got != "42" {
+		t.Errorf("GUID must take precedence, got %q", got)
+	}
+	want := "example.com/post?a=1&b=2"
+	if got := item.KeyWithOptions(KeyOptions{IgnoreGUID: true, NormalizeURLs: true}); got != want {
+		t.Errorf("KeyWithOptions() = %q, want %q", got, want)
+	}
+	other := Item{Link: "https://example.com/post?b=2&a=1"}
+	if got := other.KeyWithOptions(KeyOptions{NormalizeURLs: true}); got != want {
+		t.Errorf("KeyWithOptions() = %q, want %q", got, want)
+	}
+	if got := other.Key(); got != other.Link {
+		t.Errorf("Key() must not normalize, got %q", got)
+	}
+}
+
+func TestEntryKey(t *testing.T) {
+	entry := Entry{ID: "urn:1", Link: []Link{{Href: "https://example.com/1", Rel: "alternate"}}}
+	if got := entry.Key(); got != "urn:1" {
+		t.Errorf("Key() = %q, want ID", got)
+	}
+	if got := entry.KeyWithOptions(KeyOptions{IgnoreGUID: true}); got != "https://example.com/1" {
+		t.Errorf("KeyWithOptions() = %q, want link", got)
+	}
+
+	a := Entry{Title: "Post", Published: "2024-03-05T12:00:00Z"}
+	a.PublishedTime = newDateTime(a.Published, ParseOptions{})
+	b := Entry{Title: "Post", Published: "2024-03-05T13:00:00+01:00"}
+	b.PublishedTime = newDateTime(b.Published, ParseOptions{})
+	if !strings.HasPrefix(a.Key(), "sha256:") || a.Key() != b.Key() {
+		t.Errorf("keys %q and %q must be equal hashes", a.Key(), b.Key())
+	}
+}
+
+func TestDedupeWithKeyOptions(t *testing.T) {
+	channel := &Channel{Item: []Item{
+		{Title: "A", Link: "https://example.com/a?utm_campaign=x"},
+		{Title: "A again", Link: "http://example.com/a/"},
+		{Title: "B", Link: "https://example.com/b"},
+	}}
+	deduped := channel.Dedupe(func(item *Item) string {
+		return item.KeyWithOptions(KeyOptions{NormalizeURLs: true})
+	})
+	if got := itemTitles(deduped); got != "A, B" {
+		t.Errorf("Dedupe() = %q", got)
+	}
+}
+
+func TestEntryKeyIgnoresUpdated(t *testing.T) {
+	entry := Entry{Title: "Post", Published: "2024-03-05T12:00:00Z", Updated: "2024-03-05T12:00:00Z"}
+	edited := entry
+	edited.Updated = "2024-03-06T08:00:00Z"
+	edited.UpdatedTime = newDateTime(edited.Updated, ParseOptions{})
+	if entry.Key() != edited.Key() {
+		t.Errorf("updates must not change the key: %q != %q", entry.Key(), edited.Key())
+	}
+
+	// Entries without published date are identified by title and enclosure
+	unpublished := Entry{Title: "Post", Updated: "2024-03-05T12:00:00Z"}
+	unpublished.UpdatedTime = newDateTime(unpublished.Updated, ParseOptions{})
+	if got, want := unpublished.Key(), contentHash("Post", "", ""); got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+}
+
+func TestKeyFunc(t *testing.T) {
+	options := KeyOptions{IgnoreGUID: true, NormalizeURLs: true}
+	item := &Item{GUID: "1", Link: "http://Example.com/post/?utm_source=x"}
+	if got := ItemKeyFunc(options)(item); got != "example.com/post" {
+		t.Errorf("ItemKeyFunc() = %q", got)
+	}
+	entry := &Entry{ID: "urn:1", Link: []Link{{Href: "https://example.com/post#top"}}}
+	if got := EntryKeyFunc(options)(entry); got != "example.com/post" {
+		t.Errorf("EntryKeyFunc() = %q", got)
+	}
+
+	// Merged cross-posts with URL variants are kept once
+	first := &Channel{Item: []Item{{Title: "A", Link: "https://example.com/a?utm_source=first", PubDate: "Tue, 05 Mar 2024 12:00:00 +0000"}}}
+	second := &Channel{Item: []Item{{Title: "A again", Link: "http://example.com/a/", PubDate: "Mon, 04 Mar 2024 12:00:00 +0000"}}}
+	merged := MergeRegular(MergeOptions{ItemKey: ItemKeyFunc(KeyOptions{NormalizeURLs: true})}, first, second)
+	if got := itemTitles(merged); got != "A" {
+		t.Errorf("MergeRegular() = %q", got)
+	}
+}
+
+func TestKeyIgnoresDefaultDate(t *testing.T) {
+	const rssFeed = `<rss version="2.0"><channel><title>Feed</title>
+		<item><title>Undated</title></item>
+		<item><title>Invalid</title><pubDate>someday</pubDate></item>
+		<item><title>Dated</title><pubDate>Tue, 05 Mar 2024 12:00:00 GMT</pubDate></item>
+	</channel></rss>`
+	const atomFeed = `<feed xmlns="http://www.w3.org/2005/Atom"><title>Feed</title>
+		<entry><title>Undated</title></entry>
+		<entry><title>Invalid</title><published>someday</published></entry>
+		<entry><title>Dated</title><published>2024-03-05T12:00:00Z</published></entry>
+	</feed>`
+	ctx := context.Background()
+	var itemKeys, entryKeys [2][]string
+	for i, defaultDate := range []time.Time{time.Now(), time.Now().Add(time.Hour)} {
+		options := ParseOptions{DefaultDate: defaultDate}
+		channel, err := ParseRegularWithOptions(ctx, strings.NewReader(rssFeed), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range channel.Item {
+			itemKeys[i] = append(itemKeys[i], channel.Item[j].Key())
+		}
+		feed, err := ParseAtomWithOptions(ctx, strings.NewReader(atomFeed), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range feed.Entry {
+			entryKeys[i] = append(entryKeys[i], feed.Entry[j].Key())
+		}
+	}
+	if strings.Join(itemKeys[0], ",") != strings.Join(itemKeys[1], ",") {
+		t.Errorf("item keys changed with the default date: %q != %q", itemKeys[0], itemKeys[1])
+	}
+	if strings.Join(entryKeys[0], ",") != strings.Join(entryKeys[1], ",") {
+		t.Errorf("entry keys changed with the default date: %q != %q", entryKeys[0], entryKeys[1])
+	}
+	if got, want := itemKeys[0][0], contentHash("Undated", "", ""); got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+	if got, want := itemKeys[0][1], contentHash("Invalid", "someday", ""); got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+	if got, want := entryKeys[0][2], contentHash("Dated", "2024-03-05T12:00:00Z", ""); got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// Warning describes a problem in a feed document that was
+// repaired or skipped when parsing in lenient mode.
+type Warning struct {
+	// Line is the line number of the problem, 0 if unknown
+	Line int
+
+	// Message describes the problem
+	Message string
+}
+
+// String implements the fmt.Stringer interface.
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+	}
+	return w.Message
+}
+
+// ParseRegularLenient parses an RSS 2.0 feed from an io.Reader, tolerating
+// common errors of real-world feeds that make ParseRegular fail:
+//
+//   - Whitespace before the XML declaration
+//   - Control characters that are not allowed in XML
+//   - Unescaped ampersands and HTML entities like &nbsp; that are not declared in XML
+//   - Unclosed or mismatched tags
+//   - Truncated documents, in which case the items before the error are returned
+//
+// Every repaired problem is reported as Warning.
+// An error is only returned if no channel could be decoded at all.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func ParseRegularLenient(ctx context.Context, r io.Reader) (*Channel, []Warning, error) {
+	var channel *Channel
+	warnings, err := decodeLenient(ctx, r, "item", "</channel></rss>", func(d *xml.Decoder) (err error) {
+		channel, err = decodeRegular(d)
+		return err
+	})
+	if err != nil {
+		return nil, warnings, err
+	}
+	channel.resolveDates(ParseOptions{})
+	return channel, warnings, nil
+}
+
+// ParseAtomLenient parses an Atom 1.0 feed from an io.Reader, tolerating
+// the same errors as ParseRegularLenient and returning the entries before
+// a fatal error together with warnings about the repaired problems.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func ParseAtomLenient(ctx context.Context, r io.Reader) (*Feed, []Warning, error) {
+	var feed *Feed
+	warnings, err := decodeLenient(ctx, r, "entry", "</feed>", func(d *xml.Decoder) (err error) {
+		feed, err = decodeAtom(d, &url.URL{})
+		return err
+	})
+	if err != nil {
+		return nil, warnings, err
+	}
+	feed.resolveDates(ParseOptions{})
+	return feed, warnings, nil
+}
+
+// decodeLenient cleans the document and decodes it with a non-strict decoder.
+// If decoding fails, the document is truncated after the last complete
+// element with the name itemTag before the error, the closing tags are
+// appended and decoding is retried.
+func decodeLenient(ctx context.Context, r io.Reader, itemTag, closingTags string, decode func(*xml.Decoder) error) ([]Warning, error) {
+	// Check if context is cancelled before starting
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Convert to UTF-8 before cleaning if the encoding is known from the BOM,
+	// otherwise it is decoded later and cleaning only touches ASCII characters
+	r, isUTF8 := utf8Reader(r, "")
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data, warnings := cleanXML(data)
+
+	err = decode(newLenientDecoder(data, isUTF8))
+	if err == nil {
+		return warnings, nil
+	}
+	warnings = append(warnings, errorWarning(err))
+
+	// Retry with the document truncated after the last complete item
+	// before the error, or before the first item
+	offset := errorOffset(data, err)
+	endTag := []byte("</" + itemTag + ">")
+	cut := bytes.LastIndex(data[:offset], endTag)
+	if cut >= 0 {
+		cut += len(endTag)
+	} else if cut = bytes.Index(data, []byte("<"+itemTag)); cut < 0 {
+		cut = offset
+	}
+	truncated := append(data[:cut:cut], closingTags...)
+	if retryErr := decode(newLenientDecoder(truncated, isUTF8)); retryErr != nil {
+		return warnings, err
+	}
+	warnings = append(warnings, Warning{
+		Line:    bytes.Count(data[:cut], []byte("\n")) + 1,
+		Message: "ignored the rest of the document after this line",
+	})
+	return warnings, nil
+}
+
+func newLenientDecoder(data []byte, isUTF8 bool) *xml.Decoder {
+	xmlDecoder := newUTF8Decoder(bytes.NewReader(data), isUTF8)
+	xmlDecoder.Strict = false
+	xmlDecoder.Entity = xml.HTMLEntity
+	return xmlDecoder
+}
+
+// errorWarning converts a decoding error to a warning.
+func errorWarning(err error) Warning {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return Warning{Line: syntaxErr.Line, Message: syntaxErr.Msg}
+	}
+	return Warning{Message: err.Error()}
+}
+
+// errorOffset returns the byte offset of the start of the line of a syntax error,
+// or the end of the data if the position is unknown.
+func errorOffset(data []byte, err error) int {
+	var syntaxErr *xml.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return len(data)
+	}
+	offset := 0
+	for line := 1; line < syntaxErr.Line; line++ {
+		i := bytes.IndexByte(data[offset:], '\n')
+		if i < 0 {
+			return len(data)
+		}
+		offset += i + 1
+	}
+	return offset
+}
+
+// cleanXML removes leading whitespace and control characters,
+// escapes bare ampersands and replaces HTML entities with character references.
+// CDATA sections and comments are not changed.
+// Repeated problems of the same kind are reported once with the first line and a count.
+func cleanXML(data []byte) ([]byte, []Warning) {
+	var warnings []Warning
+	counts := make(map[string]int)
+	warn := func(line int, message string) {
+		if counts[message] == 0 {
+			warnings = append(warnings, Warning{Line: line, Message: message})
+		}
+		counts[message]++
+	}
+
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) < len(data) {
+		data = trimmed
+		warn(1, "removed whitespace before the XML declaration")
+	}
+
+	cleaned := make([]byte, 0, len(data))
+	line := 1
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+		case c < 0x20 && c != '\t' && c != '\r':
+			warn(line, "removed control characters")
+			continue
+		case c == '<':
+			if n := unparsedSectionLength(data[i:]); n > 0 {
+				line += bytes.Count(data[i:i+n], []byte("\n"))
+				cleaned = append(cleaned, data[i:i+n]...)
+				i += n - 1
+				continue
+			}
+		case c == '&':
+			replacement, n, warning := cleanEntity(data[i:])
+			if warning != "" {
+				warn(line, warning)
+			}
+			cleaned = append(cleaned, replacement...)
+			i += n - 1
+			continue
+		}
+		cleaned = append(cleaned, c)
+	}
+
+	for i, w := range warnings {
+		if count := counts[w.Message]; count > 1 {
+			warnings[i].Message = fmt.Sprintf("%s (%d times)", w.Message, count)
+		}
+	}
+	return cleaned, warnings
+}
+
+// unparsedSectionLength returns the length of the CDATA section or
+// comment at the start of data, or 0 if data starts with neither.
+func unparsedSectionLength(data []byte) int {
+	for _, section := range [][2]string{{"<![CDATA[", "]]>"}, {"<!--", "-->"}} {
+		if !bytes.HasPrefix(data, []byte(section[0])) {
+			continue
+		}
+		end := bytes.Index(data, []byte(section[1]))
+		if end < 0 {
+			return len(data)
+		}
+		return end + len(section[1])
+	}
+	return 0
+}
+
+// cleanEntity checks the entity or character reference at the start of data.
+// It returns the replacement, the length of the replaced input
+// and a warning if the input was changed.
+func cleanEntity(data []byte) (replacement []byte, length int, warning string) {
+	end := bytes.IndexByte(data, ';')
+	if end < 0 || end > 32 {
+		return []byte("&amp;"), 1, "escaped bare ampersand"
+	}
+	name := string(data[1:end])
+	switch {
+	case isCharacterReference(name):
+		return data[:end+1], end + 1, ""
+	case name == "amp" || name == "lt" || name == "gt" || name == "quot" || name == "apos":
+		return data[:end+1], end + 1, ""
+	}
+	if text, ok := xml.HTMLEntity[name]; ok {
+		var b []byte
+		for _, r := range text {
+			b = append(b, "&#"+strconv.Itoa(int(r))+";"...)
+		}
+		return b, end + 1, "replaced HTML entities"
+	}
+	return []byte("&amp;"), 1, "escaped bare ampersand"
+}
+
+// isCharacterReference checks if name is the part of a numeric
+// character reference like &#160; or &#xA0; between & and ;.
+func isCharacterReference(name string) bool {
+	if len(name) < 2 || name[0] != '#' {
+		return false
+	}
+	base := 10
+	digits := name[1:]
+	if digits[0] == 'x' || digits[0] == 'X' {
+		base = 16
+		digits = digits[1:]
+	}
+	_, err := strconv.ParseUint(digits, base, 32)
+	return err == nil
+}
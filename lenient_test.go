@@ -0,0 +1,126 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseRegularLenient tests recovery from common feed errors
+func TestParseRegularLenient(t *testing.T) {
+	rssData := "\xEF\xBB\xBF\n  <?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+		`<rss version="2.0">
+	<channel>
+		<title>Fish & Chips&nbsp;Weekly</title>
+		<item>
+			<title>First &hellip; &amp; more` + "\x0B" + `</title>
+			<description><![CDATA[<p>Keep &nbsp; as is</p>]]></description>
+		</item>
+		<item>
+			<title>Second</title>
+		</item>
+		<item>
+			<title>Trunc`
+
+	channel, warnings, err := ParseRegularLenient(context.Background(), strings.NewReader(rssData))
+	if err != nil {
+		t.Fatalf("ParseRegularLenient failed: %v", err)
+	}
+
+	if channel.Title != "Fish & Chips Weekly" {
+		t.Errorf("Expected title 'Fish & Chips Weekly', got %q", channel.Title)
+	}
+	if len(channel.Item) != 2 {
+		t.Fatalf("Expected 2 items before the truncation, got %d", len(channel.Item))
+	}
+	if channel.Item[0].Title != "First … & more" {
+		t.Errorf("Expected title 'First … & more', got %q", channel.Item[0].Title)
+	}
+	if channel.Item[0].Description != "<p>Keep &nbsp; as is</p>" {
+		t.Errorf("Expected CDATA to be unchanged, got %q", channel.Item[0].Description)
+	}
+
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	all := strings.Join(messages, "\n")
+	for _, expected := range []string{
+		"removed whitespace before the XML declaration",
+		"line 4: escaped bare ampersand",
+		"line 4: replaced HTML entities (2 times)",
+		"line 6: removed control characters",
+		"ignored the rest of the document",
+	} {
+		if !strings.Contains(all, expected) {
+			t.Errorf("Expected warning %q, got:\n%s", expected, all)
+		}
+	}
+}
+
+// TestParseRegularLenientValidFeed tests that valid feeds produce no warnings
+func TestParseRegularLenientValidFeed(t *testing.T) {
+	rssData := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Valid &amp; fine</title><item><title>Item</title></item></channel></rss>`
+
+	channel, warnings, err := ParseRegularLenient(context.Background(), strings.NewReader(rssData))
+	if err != nil {
+		t.Fatalf("ParseRegularLenient failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+	if channel.Title != "Valid & fine" || len(channel.Item) != 1 {
+		t.Errorf("Unexpected channel: %+v", channel)
+	}
+
+	if _, _, err := ParseRegularLenient(context.Background(), strings.NewReader("This is not valid XML")); err == nil {
+		t.Error("Expected error for document without XML, got nil")
+	}
+}
+
+// TestParseAtomLenient tests recovery of a truncated Atom feed
+func TestParseAtomLenient(t *testing.T) {
+	atomData := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry><id>1</id><title>One&mdash;two</title></entry>
+	<entry><id>2</id><title>Brok`
+
+	feed, warnings, err := ParseAtomLenient(context.Background(), strings.NewReader(atomData))
+	if err != nil {
+		t.Fatalf("ParseAtomLenient failed: %v", err)
+	}
+	if len(feed.Entry) != 1 || feed.Entry[0].Title != "One—two" {
+		t.Errorf("Unexpected entries: %+v", feed.Entry)
+	}
+	if len(warnings) == 0 {
+		t.Error("Expected warnings, got none")
+	}
+}
+
+// TestParseRegularLenientAllTestFiles tests that lenient parsing of valid
+// feeds returns the same items as strict parsing
+func TestParseRegularLenientAllTestFiles(t *testing.T) {
+	ctx := context.Background()
+	for _, filename := range []string{"podcast.rss", "remoteok.io.rss", "techcrunch.rss", "wordpress.rss"} {
+		t.Run(filename, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(testDataDir, filename))
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+			strict, err := ParseRegular(ctx, bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ParseRegular failed: %v", err)
+			}
+			lenient, _, err := ParseRegularLenient(ctx, bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ParseRegularLenient failed: %v", err)
+			}
+			if len(lenient.Item) != len(strict.Item) {
+				t.Errorf("Expected %d items, got %d", len(strict.Item), len(lenient.Item))
+			}
+		})
+	}
+}
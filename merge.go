@@ -0,0 +1,160 @@
+package rss
+
+import (
+	"time"
+)
+
+// MergeOptions configures the aggregate feed created by MergeRegular and MergeAtom.
+type MergeOptions struct {
+	// Title is the title of the aggregate feed
+	Title string
+
+	// Link is the URL of the website of the aggregate feed
+	Link string
+
+	// Description describes the aggregate feed, defaults to Title for RSS
+	Description string
+
+	// ID is the identifier of the aggregate Atom feed, defaults to Link
+	ID string
+
+	// Limit is the maximum number of items or entries, 0 means no limit
+	Limit int
+
+	// ItemKey identifies cross-posted items in MergeRegular,
+	// nil means DefaultItemKey
+	ItemKey func(item *Item) string
+
+	// EntryKey identifies cross-posted entries in MergeAtom,
+	// nil means DefaultEntryKey
+	EntryKey func(entry *Entry) string
+
+	// FeedURLs are the URLs the channels or feeds were fetched from,
+	// in the order of the arguments of MergeRegular and MergeAtom.
+	// They are recorded in the source elements of the items and entries.
+	// A missing or empty URL means the self link of the feed is used, if any
+	FeedURLs []string
+}
+
+// feedURL returns the URL of the i-th merged feed, or selfURL if not set.
+func (o *MergeOptions) feedURL(i int, selfURL string) string {
+	if i < len(o.FeedURLs) && o.FeedURLs[i] != "" {
+		return o.FeedURLs[i]
+	}
+	return selfURL
+}
+
+// MergeRegular combines the items of the channels into a new channel,
+// as used by "planet" style aggregators.
+//
+// The items are sorted newest first, see Channel.SortByDate. Items with the
+// same key are cross-posts of which only the newest is kept. Every item records
+// the channel it came from as source element, using the URL of the channel's
+// feed from MergeOptions.FeedURLs or its atom:link with rel="self" as URL,
+// which is empty if neither is known. Items that already have a source, for
+// example from another aggregate, keep it. The channels are not modified.
+//
+// The last build date of the result is the date of its newest item.
+func MergeRegular(options MergeOptions, channels ...*Channel) *Channel {
+	merged := &Channel{
+		Title:       options.Title,
+		Link:        options.Link,
+		Description: options.Description,
+	}
+	if merged.Description == "" {
+		merged.Description = options.Title
+	}
+	for i, channel := range channels {
+		if channel == nil {
+			continue
+		}
+		sourceURL := options.feedURL(i, selfLink(channel.AtomLinks))
+		for _, item := range channel.Item {
+			if item.Source == nil {
+				item.Source = &ItemSource{URL: sourceURL, Title: channel.Title}
+			}
+			item.XMLBase = firstNonEmpty(item.XMLBase, channel.XMLBase)
+			merged.Item = append(merged.Item, item)
+		}
+	}
+
+	merged = merged.SortByDate().Dedupe(options.ItemKey)
+	if options.Limit > 0 && len(merged.Item) > options.Limit {
+		merged.Item = merged.Item[:options.Limit]
+	}
+	if len(merged.Item) > 0 {
+		if latest := merged.Item[0].Time(); !latest.IsZero() {
+			merged.LastBuildDate = Date(latest.Format(time.RFC1123Z))
+			merged.LastBuildTime = DateTime{Time: latest, Raw: string(merged.LastBuildDate)}
+		}
+	}
+	return merged
+}
+
+// MergeAtom combines the entries of the feeds into a new feed,
+// as used by "planet" style aggregators.
+//
+// The entries are sorted newest first, see Feed.SortByDate. Entries with the
+// same key are cross-posts of which only the newest is kept. Every entry records
+// the ID, title, update time and links of the feed it came from as source element.
+// The URL from MergeOptions.FeedURLs replaces the self link of the feed if set.
+// Entries that already have a source, for example from another aggregate,
+// keep it. The feeds are not modified.
+//
+// The update time of the result is the latest update time of its entries.
+func MergeAtom(options MergeOptions, feeds ...*Feed) *Feed {
+	merged := &Feed{
+		ID:    firstNonEmpty(options.ID, options.Link),
+		Title: options.Title,
+	}
+	if options.Link != "" {
+		merged.Link = []Link{{Href: options.Link, Rel: "alternate"}}
+	}
+	for i, feed := range feeds {
+		if feed == nil {
+			continue
+		}
+		sourceLinks := feed.Link
+		if sourceURL := options.feedURL(i, ""); sourceURL != "" {
+			sourceLinks = []Link{{Href: sourceURL, Rel: "self"}}
+			for _, link := range feed.Link {
+				if link.Rel != "self" {
+					sourceLinks = append(sourceLinks, link)
+				}
+			}
+		}
+		for _, entry := range feed.Entry {
+			if entry.Source == nil {
+				entry.Source = &Source{
+					ID:      feed.ID,
+					Title:   feed.Title,
+					Updated: feed.Updated,
+					Link:    sourceLinks,
+				}
+			}
+			entry.XMLBase = firstNonEmpty(entry.XMLBase, feed.XMLBase)
+			merged.Entry = append(merged.Entry, entry)
+		}
+	}
+
+	merged = merged.SortByDate().Dedupe(options.EntryKey)
+	if options.Limit > 0 && len(merged.Entry) > options.Limit {
+		merged.Entry = merged.Entry[:options.Limit]
+	}
+	var latest time.Time
+	for i := range merged.Entry {
+		entry := &merged.Entry[i]
+		updated := entry.UpdatedTime.Time
+		if updated.IsZero() {
+			updated = entry.Time()
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+	}
+	if !latest.IsZero() {
+		merged.Updated = latest.Format(time.RFC3339)
+		merged.UpdatedTime = DateTime{Time: latest, Raw: merged.Updated}
+	}
+	return merged
+}
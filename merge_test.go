@@ -0,0 +1,159 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestMergeRegular tests merging RSS channels into an aggregate channel
+func TestMergeRegular(t *testing.T) {
+	first, err := ParseRegular(context.Background(), strings.NewReader(`<rss version="2.0"><channel>
+		<title>First</title><link>https://first.example.com/</link>
+		<item><title>First old</title><guid>https://first.example.com/1</guid><pubDate>Fri, 01 Mar 2024 12:00:00 +0000</pubDate></item>
+		<item><title>Cross-post</title><guid>https://example.com/shared</guid><pubDate>Tue, 05 Mar 2024 12:00:00 +0000</pubDate></item>
+	</channel></rss>`))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	second, err := ParseRegular(context.Background(), strings.NewReader(`<rss version="2.0"><channel>
+		<title>Second</title><link>https://second.example.com/</link>
+		<atom:link xmlns:atom="http://www.w3.org/2005/Atom" rel="self" href="https://second.example.com/feed"/>
+		<item><title>Second new</title><guid>https://second.example.com/1</guid><pubDate>Thu, 07 Mar 2024 12:00:00 +0000</pubDate></item>
+		<item><title>Cross-post again</title><guid>https://example.com/shared</guid><pubDate>Wed, 06 Mar 2024 12:00:00 +0000</pubDate></item>
+		<item><title>Relayed</title><guid>https://third.example.com/1</guid><pubDate>Mon, 04 Mar 2024 12:00:00 +0000</pubDate><source url="https://third.example.com/feed">Third</source></item>
+	</channel></rss>`))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+
+	merged := MergeRegular(MergeOptions{
+		Title:    "Planet",
+		Link:     "https://planet.example.com/",
+		FeedURLs: []string{"https://first.example.com/feed.rss"},
+	}, first, nil, second)
+	if titles := itemTitles(merged); titles != "Second new, Cross-post again, Relayed, First old" {
+		t.Errorf("Unexpected merged items %q", titles)
+	}
+	if merged.Description != "Planet" || merged.LastBuildDate != "Thu, 07 Mar 2024 12:00:00 +0000" {
+		t.Errorf("Unexpected merged channel %q, %q", merged.Description, merged.LastBuildDate)
+	}
+	expectedSources := []ItemSource{
+		{URL: "https://second.example.com/feed", Title: "Second"},
+		{URL: "https://second.example.com/feed", Title: "Second"},
+		{URL: "https://third.example.com/feed", Title: "Third"},
+		{URL: "https://first.example.com/feed.rss", Title: "First"},
+	}
+	for i, expected := range expectedSources {
+		if source := merged.Item[i].Source; source == nil || *source != expected {
+			t.Errorf("Expected source %+v for item %d, got %+v", expected, i, source)
+		}
+	}
+	if first.Item[0].Source != nil {
+		t.Error("Expected source channel to be unchanged")
+	}
+
+	limited := MergeRegular(MergeOptions{Title: "Planet", Limit: 2}, first, second)
+	if titles := itemTitles(limited); titles != "Second new, Cross-post again" {
+		t.Errorf("Unexpected limited items %q", titles)
+	}
+
+	// The website of a channel is not the URL of its feed
+	unknown := MergeRegular(MergeOptions{Title: "Planet"}, first)
+	if source := unknown.Item[0].Source; source == nil || source.URL != "" || source.Title != "First" {
+		t.Errorf("Expected source without URL, got %+v", source)
+	}
+	var unknownBuf bytes.Buffer
+	if err := WriteRegular(&unknownBuf, unknown); err != nil {
+		t.Fatalf("WriteRegular failed: %v", err)
+	}
+	if !strings.Contains(unknownBuf.String(), `<source>First</source>`) {
+		t.Errorf("Expected source element without url in\n%s", unknownBuf.String())
+	}
+
+	// The aggregate can be written and parsed again
+	var buf bytes.Buffer
+	if err := WriteRegular(&buf, merged); err != nil {
+		t.Fatalf("WriteRegular failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<source url="https://third.example.com/feed">Third</source>`) {
+		t.Errorf("Expected source element in\n%s", buf.String())
+	}
+	parsed, err := ParseRegular(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	if len(parsed.Item) != 4 || parsed.Item[3].Source == nil || parsed.Item[3].Source.Title != "First" {
+		t.Errorf("Unexpected parsed aggregate %+v", parsed.Item)
+	}
+}
+
+// TestMergeAtom tests merging Atom feeds into an aggregate feed
+func TestMergeAtom(t *testing.T) {
+	first, err := ParseAtom(context.Background(), strings.NewReader(`<feed xmlns="http://www.w3.org/2005/Atom">
+		<id>urn:first</id><title>First</title><updated>2024-03-05T12:00:00Z</updated>
+		<link rel="self" href="https://first.example.com/feed"/>
+		<entry><id>urn:shared</id><title>Cross-post</title><updated>2024-03-05T12:00:00Z</updated></entry>
+		<entry><id>urn:first:1</id><title>First old</title><updated>2024-03-01T12:00:00Z</updated></entry>
+	</feed>`))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	second, err := ParseAtom(context.Background(), strings.NewReader(`<feed xmlns="http://www.w3.org/2005/Atom">
+		<id>urn:second</id><title>Second</title><updated>2024-03-07T12:00:00Z</updated>
+		<entry><id>urn:second:1</id><title>Second new</title><updated>2024-03-07T12:00:00Z</updated></entry>
+		<entry><id>urn:shared</id><title>Cross-post old</title><updated>2024-03-02T12:00:00Z</updated></entry>
+	</feed>`))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+
+	merged := MergeAtom(MergeOptions{Title: "Planet", Link: "https://planet.example.com/"}, first, second)
+	if titles := entryTitles(merged); titles != "Second new, Cross-post, First old" {
+		t.Errorf("Unexpected merged entries %q", titles)
+	}
+	if merged.ID != "https://planet.example.com/" || merged.Updated != "2024-03-07T12:00:00Z" || merged.URL() != "https://planet.example.com/" {
+		t.Errorf("Unexpected merged feed %q, %q, %q", merged.ID, merged.Updated, merged.URL())
+	}
+	source := merged.Entry[1].Source
+	if source == nil || source.ID != "urn:first" || source.Title != "First" || selfLink(source.Link) != "https://first.example.com/feed" {
+		t.Errorf("Unexpected source %+v", source)
+	}
+	if source := merged.Entry[0].Source; source == nil || len(source.Link) != 0 {
+		t.Errorf("Expected source without links, got %+v", source)
+	}
+
+	// Feed URLs replace the self links
+	fetched := MergeAtom(MergeOptions{
+		Title:    "Planet",
+		FeedURLs: []string{"https://first.example.com/feed.atom", "https://second.example.com/feed.atom"},
+	}, first, second)
+	expectedLinks := []string{"https://second.example.com/feed.atom", "https://first.example.com/feed.atom", "https://first.example.com/feed.atom"}
+	for i, expected := range expectedLinks {
+		if links := fetched.Entry[i].Source.Link; len(links) != 1 || selfLink(links) != expected {
+			t.Errorf("Expected self link %q for entry %d, got %+v", expected, i, links)
+		}
+	}
+	if selfLink(first.Link) != "https://first.example.com/feed" {
+		t.Error("Expected source feed to be unchanged")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, merged); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+	parsed, err := ParseAtom(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	if source := parsed.Entry[0].Source; source == nil || source.ID != "urn:second" {
+		t.Errorf("Unexpected parsed source %+v", source)
+	}
+
+	// Sources survive the conversion to RSS
+	channel := merged.ToRegular()
+	if source := channel.Item[1].Source; source == nil || source.URL != "https://first.example.com/feed" || source.Title != "First" {
+		t.Errorf("Unexpected converted source %+v", source)
+	}
+}
@@ -0,0 +1,329 @@
+package rss
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	htmlparser "golang.org/x/net/html"
+)
+
+// trackingParams are query parameters removed by StripTrackingParams
+// in addition to all parameters starting with "utm_".
+var trackingParams = []string{
+	"fbclid",
+	"gclid",
+	"mc_cid",
+	"mc_eid",
+}
+
+// urlAttributes are the HTML attributes containing URLs
+// that are resolved when normalizing HTML content.
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"poster": true,
+	"srcset": true,
+}
+
+// Normalize cleans up the channel and its items in place so downstream code receives clean values:
+//
+//   - Relative URLs of links, comments, enclosures, images and in HTML attributes (href, src, srcset)
+//     are resolved against xml:base, the feed URL and the channel link.
+//   - Tracking parameters like utm_source are stripped from links.
+//   - HTML entities in titles are decoded.
+//   - Leading and trailing whitespace is trimmed and whitespace in titles is collapsed.
+//
+// The feedURL is the URL the feed was fetched from and may be empty if unknown.
+// Returns an error if the feedURL can not be parsed.
+func (c *Channel) Normalize(feedURL string) error {
+	feedBase, err := url.Parse(strings.TrimSpace(feedURL))
+	if err != nil {
+		return fmt.Errorf("invalid feed URL: %w", err)
+	}
+
+	base := resolveBase(feedBase, c.XMLBase)
+	c.Link = normalizeURL(base, c.Link)
+	if !base.IsAbs() {
+		// Fall back to the channel link as base for feeds read from files
+		if link, err := url.Parse(c.Link); err == nil && link.IsAbs() {
+			base = link
+		}
+	}
+
+	c.Title = normalizeTitle(c.Title)
+	c.Description = strings.TrimSpace(c.Description)
+	c.Language = strings.TrimSpace(c.Language)
+	c.LastBuildDate = Date(strings.TrimSpace(string(c.LastBuildDate)))
+	if c.Image != nil {
+		c.Image.URL = resolveURL(base, strings.TrimSpace(c.Image.URL))
+		c.Image.Link = normalizeURL(base, c.Image.Link)
+	}
+	if c.ITunesImage != nil {
+		c.ITunesImage.Href = resolveURL(base, strings.TrimSpace(c.ITunesImage.Href))
+	}
+
+	for i := range c.Item {
+		c.Item[i].normalize(base)
+	}
+	return nil
+}
+
+// normalize cleans up the item in place, see Channel.Normalize.
+func (item *Item) normalize(channelBase *url.URL) {
+	base := resolveBase(channelBase, item.XMLBase)
+
+	item.Title = normalizeTitle(item.Title)
+	item.Link = normalizeURL(base, item.Link)
+	item.Comments = normalizeURL(base, item.Comments)
+	item.PubDate = Date(strings.TrimSpace(string(item.PubDate)))
+	item.GUID = strings.TrimSpace(item.GUID)
+	item.Author = strings.TrimSpace(item.Author)
+	for i, category := range item.Category {
+		item.Category[i] = strings.TrimSpace(category)
+	}
+	for i := range item.Enclosure {
+		item.Enclosure[i].URL = resolveURL(base, strings.TrimSpace(item.Enclosure[i].URL))
+		item.Enclosure[i].Type = strings.TrimSpace(item.Enclosure[i].Type)
+	}
+	for i := range item.Media {
+		item.Media[i].URL = resolveURL(base, strings.TrimSpace(item.Media[i].URL))
+		item.Media[i].Type = strings.TrimSpace(item.Media[i].Type)
+	}
+	for i := range item.MediaThumbnail {
+		item.MediaThumbnail[i].URL = resolveURL(base, strings.TrimSpace(item.MediaThumbnail[i].URL))
+	}
+	if item.ITunesImage != nil {
+		item.ITunesImage.Href = resolveURL(base, strings.TrimSpace(item.ITunesImage.Href))
+	}
+	item.Description = resolveHTMLURLs(base, strings.TrimSpace(item.Description))
+	item.Content = resolveHTMLURLs(base, strings.TrimSpace(item.Content))
+	item.FullText = resolveHTMLURLs(base, strings.TrimSpace(item.FullText))
+}
+
+// Normalize cleans up the feed and its entries in place,
+// see Channel.Normalize for the applied rules.
+// Relative URLs in entry content are not changed, use Content.HTML
+// to get the content with resolved URLs.
+//
+// The feedURL is the URL the feed was fetched from and may be empty if unknown.
+// Returns an error if the feedURL can not be parsed.
+func (f *Feed) Normalize(feedURL string) error {
+	feedBase, err := url.Parse(strings.TrimSpace(feedURL))
+	if err != nil {
+		return fmt.Errorf("invalid feed URL: %w", err)
+	}
+	f.resolveBases(feedBase)
+
+	f.ID = strings.TrimSpace(f.ID)
+	f.Title = normalizeTitle(f.Title)
+	f.Updated = strings.TrimSpace(f.Updated)
+	normalizeLinks(f.Link)
+
+	for i := range f.Entry {
+		entry := &f.Entry[i]
+		entry.ID = strings.TrimSpace(entry.ID)
+		entry.Title = normalizeTitle(entry.Title)
+		entry.Updated = strings.TrimSpace(entry.Updated)
+		entry.Published = strings.TrimSpace(entry.Published)
+		normalizeLinks(entry.Link)
+		for j := range entry.Author {
+			author := &entry.Author[j]
+			author.Name = strings.TrimSpace(author.Name)
+			author.URI = strings.TrimSpace(author.URI)
+			author.Email = strings.TrimSpace(author.Email)
+		}
+	}
+	return nil
+}
+
+// normalizeLinks strips tracking parameters from already resolved links.
+func normalizeLinks(links []Link) {
+	for i := range links {
+		links[i].Href = StripTrackingParams(links[i].Href)
+	}
+}
+
+// StripTrackingParams removes tracking query parameters like utm_source,
+// utm_medium or fbclid from a URL. The URL is returned unchanged
+// if it can not be parsed or has no tracking parameters.
+func StripTrackingParams(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	query := u.Query()
+	stripped := false
+	for name := range query {
+		if isTrackingParam(name) {
+			query.Del(name)
+			stripped = true
+		}
+	}
+	if !stripped {
+		return rawURL
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "utm_") {
+		return true
+	}
+	for _, p := range trackingParams {
+		if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTitle decodes HTML entities and collapses whitespace.
+func normalizeTitle(title string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(title)), " ")
+}
+
+// normalizeURL resolves a link against base and strips tracking parameters.
+func normalizeURL(base *url.URL, link string) string {
+	return StripTrackingParams(resolveURL(base, strings.TrimSpace(link)))
+}
+
+// resolveBase returns the base URL in scope of an element with the
+// given xml:base attribute value.
+func resolveBase(parent *url.URL, xmlBase string) *url.URL {
+	xmlBase = strings.TrimSpace(xmlBase)
+	if xmlBase == "" {
+		return parent
+	}
+	ref, err := url.Parse(xmlBase)
+	if err != nil {
+		return parent
+	}
+	return parent.ResolveReference(ref)
+}
+
+// resolveURL resolves a possibly relative URL against base.
+// Empty and unparseable URLs and URLs with an empty base are returned unchanged.
+func resolveURL(base *url.URL, link string) string {
+	if link == "" || base == nil || *base == (url.URL{}) {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// voidElements are HTML elements without end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// resolveHTMLURLs resolves relative URLs in the attributes of HTML content.
+// Nested xml:base attributes, as used in Atom XHTML content, change the
+// base URL for the element and its descendants.
+// Tracking parameters are stripped from href attributes.
+// Everything except the changed tags is left as is.
+func resolveHTMLURLs(base *url.URL, content string) string {
+	if !strings.Contains(content, "<") {
+		return content
+	}
+
+	type scope struct {
+		tag  string
+		base *url.URL
+	}
+	var scopes []scope
+
+	var b strings.Builder
+	changed := false
+	tokenizer := htmlparser.NewTokenizer(strings.NewReader(content))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == htmlparser.ErrorToken {
+			break
+		}
+		raw := tokenizer.Raw()
+		switch tokenType {
+		case htmlparser.StartTagToken, htmlparser.SelfClosingTagToken:
+		case htmlparser.EndTagToken:
+			name, _ := tokenizer.TagName()
+			for i := len(scopes) - 1; i >= 0; i-- {
+				if scopes[i].tag == string(name) {
+					scopes = scopes[:i]
+					break
+				}
+			}
+			b.Write(raw)
+			continue
+		default:
+			b.Write(raw)
+			continue
+		}
+
+		token := tokenizer.Token()
+		tagBase := base
+		if len(scopes) > 0 {
+			tagBase = scopes[len(scopes)-1].base
+		}
+		for _, attr := range token.Attr {
+			if attr.Key == "xml:base" {
+				tagBase = resolveBase(tagBase, attr.Val)
+			}
+		}
+		if tokenType == htmlparser.StartTagToken && !voidElements[token.Data] {
+			scopes = append(scopes, scope{tag: token.Data, base: tagBase})
+		}
+
+		tagChanged := false
+		for i, attr := range token.Attr {
+			if attr.Namespace != "" || !urlAttributes[attr.Key] {
+				continue
+			}
+			var value string
+			switch attr.Key {
+			case "srcset":
+				value = resolveSrcset(tagBase, attr.Val)
+			case "href":
+				value = normalizeURL(tagBase, attr.Val)
+			default:
+				value = resolveURL(tagBase, strings.TrimSpace(attr.Val))
+			}
+			if value != attr.Val {
+				token.Attr[i].Val = value
+				tagChanged = true
+			}
+		}
+		if tagChanged {
+			b.WriteString(token.String())
+			changed = true
+		} else {
+			b.Write(raw)
+		}
+	}
+	if !changed {
+		return content
+	}
+	return b.String()
+}
+
+// resolveSrcset resolves the URLs of an img srcset attribute
+// of the form "image.jpg 1x, image@2x.jpg 2x".
+func resolveSrcset(base *url.URL, srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = resolveURL(base, fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
@@ -0,0 +1,93 @@
+package rss
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestChannelNormalize tests URL resolution, entity decoding and trimming
+func TestChannelNormalize(t *testing.T) {
+	rssData := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+	<channel xml:base="/blog/">
+		<title>  Test   Channel </title>
+		<link>/blog</link>
+		<item>
+			<title>
+				Fish &amp;amp; Chips
+			</title>
+			<link>posts/1?utm_source=rss&amp;id=1</link>
+			<enclosure url="media/1.mp3" type="audio/mpeg"/>
+			<description><![CDATA[<p>Hi <img src="img/1.png"> <a href="/about?utm_medium=feed">about</a></p>]]></description>
+		</item>
+		<item xml:base="https://cdn.example.org/">
+			<title>Second</title>
+			<link>2</link>
+		</item>
+	</channel>
+</rss>`
+
+	channel, err := ParseRegular(context.Background(), strings.NewReader(rssData))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	if err := channel.Normalize("https://example.com/feed.xml"); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	if channel.Title != "Test Channel" {
+		t.Errorf("Expected title 'Test Channel', got '%s'", channel.Title)
+	}
+	if channel.Link != "https://example.com/blog" {
+		t.Errorf("Expected absolute channel link, got '%s'", channel.Link)
+	}
+
+	item := channel.Item[0]
+	if item.Title != "Fish & Chips" {
+		t.Errorf("Expected title 'Fish & Chips', got '%s'", item.Title)
+	}
+	if item.Link != "https://example.com/blog/posts/1?id=1" {
+		t.Errorf("Expected resolved link without tracking, got '%s'", item.Link)
+	}
+	if item.Enclosure[0].URL != "https://example.com/blog/media/1.mp3" {
+		t.Errorf("Expected resolved enclosure URL, got '%s'", item.Enclosure[0].URL)
+	}
+	expected := `<p>Hi <img src="https://example.com/blog/img/1.png"> <a href="https://example.com/about">about</a></p>`
+	if item.Description != expected {
+		t.Errorf("Expected description %q, got %q", expected, item.Description)
+	}
+
+	if channel.Item[1].Link != "https://cdn.example.org/2" {
+		t.Errorf("Expected link resolved against item xml:base, got '%s'", channel.Item[1].Link)
+	}
+}
+
+// TestNormalizeWithoutFeedURL tests that the channel link is used as fallback base
+func TestNormalizeWithoutFeedURL(t *testing.T) {
+	channel := &Channel{
+		Link: "https://example.com/",
+		Item: []Item{{Link: "/post"}},
+	}
+	if err := channel.Normalize(""); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if channel.Item[0].Link != "https://example.com/post" {
+		t.Errorf("Expected link resolved against channel link, got '%s'", channel.Item[0].Link)
+	}
+}
+
+// TestStripTrackingParams tests removal of tracking query parameters
+func TestStripTrackingParams(t *testing.T) {
+	testCases := map[string]string{
+		"https://example.com/a?utm_source=x&utm_campaign=y": "https://example.com/a",
+		"https://example.com/a?id=1&fbclid=abc":             "https://example.com/a?id=1",
+		"https://example.com/a?id=1":                        "https://example.com/a?id=1",
+		"https://example.com/a":                             "https://example.com/a",
+	}
+	for input, expected := range testCases {
+		if got := StripTrackingParams(input); got != expected {
+			t.Errorf("StripTrackingParams(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
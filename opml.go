@@ -0,0 +1,119 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// OPML represents an OPML 2.0 document, the common format
+// for exchanging feed subscription lists between feed readers.
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+
+	// Version is the OPML version, "2.0" when written by this package
+	Version string `xml:"version,attr"`
+
+	// Head contains the metadata of the document
+	Head OPMLHead `xml:"head"`
+
+	// Body contains the outlines of the document
+	Body OPMLBody `xml:"body"`
+}
+
+// OPMLHead contains the metadata of an OPML document.
+type OPMLHead struct {
+	// Title is the title of the document
+	Title string `xml:"title,omitempty"`
+
+	// DateCreated is the creation date of the document in RFC 822 format
+	DateCreated string `xml:"dateCreated,omitempty"`
+
+	// OwnerName is the name of the owner of the document
+	OwnerName string `xml:"ownerName,omitempty"`
+}
+
+// OPMLBody contains the outlines of an OPML document.
+type OPMLBody struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is an entry of an OPML document. Outlines with an XMLURL
+// are feed subscriptions, outlines without are usually folders.
+type Outline struct {
+	// Text is the displayed text of the outline
+	Text string `xml:"text,attr"`
+
+	// Title is the title of the feed, usually the same as Text
+	Title string `xml:"title,attr,omitempty"`
+
+	// Type is "rss" for feed subscriptions, regardless of the feed format
+	Type string `xml:"type,attr,omitempty"`
+
+	// XMLURL is the URL of the feed
+	XMLURL string `xml:"xmlUrl,attr,omitempty"`
+
+	// HTMLURL is the URL of the website of the feed
+	HTMLURL string `xml:"htmlUrl,attr,omitempty"`
+
+	// Outlines are the nested outlines of a folder
+	Outlines []Outline `xml:"outline,omitempty"`
+}
+
+// NewOPML returns an OPML 2.0 document with the given title and outlines.
+func NewOPML(title string, outlines ...Outline) *OPML {
+	return &OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+		Body: OPMLBody{Outlines: outlines},
+	}
+}
+
+// FeedOutline returns an outline for a feed subscription.
+func FeedOutline(title, feedURL, siteURL string) Outline {
+	return Outline{Text: title, Title: title, Type: "rss", XMLURL: feedURL, HTMLURL: siteURL}
+}
+
+// ParseOPML parses an OPML document from an io.Reader.
+// The context is used for cancellation control during parsing.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func ParseOPML(ctx context.Context, r io.Reader) (*OPML, error) {
+	// Check if context is cancelled before starting
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var opml OPML
+	if err := newDecoder(r, "").Decode(&opml); err != nil {
+		return nil, err
+	}
+	return &opml, nil
+}
+
+// Feeds returns the outlines with a feed URL, including the ones nested in folders.
+func (o *OPML) Feeds() []Outline {
+	var feeds []Outline
+	var collect func([]Outline)
+	collect = func(outlines []Outline) {
+		for _, outline := range outlines {
+			if strings.TrimSpace(outline.XMLURL) != "" {
+				feeds = append(feeds, outline)
+			}
+			collect(outline.Outlines)
+		}
+	}
+	collect(o.Body.Outlines)
+	return feeds
+}
+
+// Write writes the document as indented XML to w.
+func (o *OPML) Write(w io.Writer) error {
+	return writeXML(w, o, xml.StartElement{})
+}
@@ -0,0 +1,93 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestOPML tests parsing, flattening and writing of OPML documents
+func TestOPML(t *testing.T) {
+	opmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1.0">
+	<head><title>My Feeds</title></head>
+	<body>
+		<outline text="Tech">
+			<outline text="TechCrunch" type="rss" xmlUrl="https://techcrunch.com/feed/" htmlUrl="https://techcrunch.com/"/>
+			<outline text="Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+		</outline>
+		<outline text="Reddit" type="rss" xmlUrl="https://www.reddit.com/.rss"/>
+	</body>
+</opml>`
+
+	opml, err := ParseOPML(context.Background(), strings.NewReader(opmlData))
+	if err != nil {
+		t.Fatalf("ParseOPML failed: %v", err)
+	}
+	if opml.Head.Title != "My Feeds" {
+		t.Errorf("Expected title 'My Feeds', got %q", opml.Head.Title)
+	}
+	feeds := opml.Feeds()
+	if len(feeds) != 3 || feeds[0].HTMLURL != "https://techcrunch.com/" || feeds[2].Text != "Reddit" {
+		t.Fatalf("Unexpected feeds: %+v", feeds)
+	}
+
+	written := NewOPML("Export", FeedOutline("Go Blog", "https://go.dev/blog/feed.atom", "https://go.dev/blog/"))
+	var b bytes.Buffer
+	if err := written.Write(&b); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	parsed, err := ParseOPML(context.Background(), &b)
+	if err != nil {
+		t.Fatalf("ParseOPML of written document failed: %v", err)
+	}
+	if parsed.Version != "2.0" || len(parsed.Feeds()) != 1 || !reflect.DeepEqual(parsed.Feeds()[0], written.Body.Outlines[0]) {
+		t.Errorf("Unexpected document after round trip: %+v", parsed)
+	}
+}
+
+// TestOPMLFeeds tests collecting feeds from nested folders
+func TestOPMLFeeds(t *testing.T) {
+	opml := NewOPML("Feeds",
+		Outline{Text: "Folder", Outlines: []Outline{
+			{Text: "Subfolder", Outlines: []Outline{FeedOutline("Nested", "https://example.com/nested.rss", "")}},
+			FeedOutline("A", "https://example.com/a.rss", "https://example.com/a/"),
+			{Text: "No URL", XMLURL: " "},
+		}},
+		FeedOutline("B", "https://example.com/b.rss", ""),
+	)
+	if opml.Version != "2.0" || opml.Head.Title != "Feeds" || opml.Head.DateCreated == "" {
+		t.Errorf("Unexpected document: %+v", opml)
+	}
+
+	var urls []string
+	for _, outline := range opml.Feeds() {
+		urls = append(urls, outline.XMLURL)
+	}
+	expected := []string{"https://example.com/nested.rss", "https://example.com/a.rss", "https://example.com/b.rss"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected feeds %q, got %q", expected, urls)
+	}
+
+	if outline := FeedOutline("A", "https://example.com/a.rss", ""); outline.Text != "A" || outline.Title != "A" || outline.Type != "rss" {
+		t.Errorf("Unexpected outline: %+v", outline)
+	}
+}
+
+// TestParseOPMLInvalid tests errors for documents that are not OPML
+func TestParseOPMLInvalid(t *testing.T) {
+	if _, err := ParseOPML(context.Background(), strings.NewReader(`<opml><body><outline`)); err == nil {
+		t.Error("Expected error for truncated document")
+	}
+	if _, err := ParseOPML(context.Background(), strings.NewReader(`<rss version="2.0"></rss>`)); err == nil {
+		t.Error("Expected error for RSS document")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseOPML(ctx, strings.NewReader(`<opml version="2.0"></opml>`)); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
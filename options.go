@@ -0,0 +1,38 @@
+package rss
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ParseOptions configures the parsing of feeds.
+// The zero value is the default used by ParseRegular and ParseAtom.
+type ParseOptions struct {
+	// RequireItems rejects valid feeds without items or entries
+	// with an error wrapping ErrEmptyFeed. By default they are accepted.
+	RequireItems bool
+
+	// Location is used for dates without time zone, like "2006-01-02 15:04:05".
+	// Nil means UTC.
+	Location *time.Location
+
+	// DefaultDate is used as parsed time of missing or invalid dates,
+	// for example Item.PubTime if an item has no pubDate.
+	// The raw date and the invalid flag of DateTime are kept as they are.
+	DefaultDate time.Time
+}
+
+// ParseRegularWithOptions parses an RSS feed from an io.Reader like ParseRegular
+// using the given options.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func ParseRegularWithOptions(ctx context.Context, r io.Reader, options ParseOptions) (*Channel, error) {
+	return parseRegular(ctx, r, "", options)
+}
+
+// ParseAtomWithOptions parses an Atom feed from an io.Reader like ParseAtom
+// using the given options.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func ParseAtomWithOptions(ctx context.Context, r io.Reader, options ParseOptions) (*Feed, error) {
+	return parseAtom(ctx, r, "", nil, options)
+}
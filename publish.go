@@ -0,0 +1,175 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PublishError is the error of a publish notification to a hub.
+type PublishError struct {
+	// Hub is the URL of the hub
+	Hub string
+
+	// Attempts is the number of notifications sent to the hub
+	Attempts int
+
+	// Err is the error of the last attempt
+	Err error
+}
+
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("failed to notify hub %s after %d attempts: %v", e.Hub, e.Attempts, e.Err)
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Err
+}
+
+// Publisher announces the WebSub hubs of a feed produced by the application
+// and notifies them when the feed changes, so that the hubs push the new
+// content to their subscribers.
+type Publisher struct {
+	// Hubs are the URLs of the hubs to notify
+	Hubs []string
+
+	// Self is the public URL of the feed, the topic of the subscriptions
+	Self string
+
+	// Client is used for requests to hubs, nil means http.DefaultClient
+	Client *http.Client
+
+	// Retries is the number of additional attempts for notifications
+	// that failed with a network error or a server error status
+	Retries int
+
+	// RetryDelay is the delay before the first retry, doubled for each further
+	// retry. Zero means one second.
+	RetryDelay time.Duration
+}
+
+// WriteRegular writes the channel like WriteRegular with atom:link elements
+// for the hubs and the self URL, replacing existing hub and self links.
+// The channel is not modified.
+func (p *Publisher) WriteRegular(w io.Writer, channel *Channel) error {
+	withLinks := *channel
+	withLinks.AtomLinks = p.links(channel.AtomLinks)
+	return WriteRegular(w, &withLinks)
+}
+
+// WriteAtom writes the feed like WriteAtom with links for the hubs
+// and the self URL, replacing existing hub and self links.
+// The feed is not modified.
+func (p *Publisher) WriteAtom(w io.Writer, feed *Feed) error {
+	withLinks := *feed
+	withLinks.Link = p.links(feed.Link)
+	return WriteAtom(w, &withLinks)
+}
+
+// links returns the links without hub and self links,
+// followed by the links of the publisher.
+func (p *Publisher) links(links []Link) []Link {
+	var result []Link
+	for _, link := range links {
+		if !hasLinkRel(link.Rel, "hub") && !hasLinkRel(link.Rel, "self") {
+			result = append(result, link)
+		}
+	}
+	for _, hub := range p.Hubs {
+		result = append(result, Link{Href: hub, Rel: "hub"})
+	}
+	if p.Self != "" {
+		result = append(result, Link{Href: p.Self, Rel: "self"})
+	}
+	return result
+}
+
+// Publish notifies all hubs that the feed at Self has changed.
+// Failed notifications are retried as configured.
+//
+// Returns the joined PublishError of every hub that could not be notified,
+// or the context error if the context is done while waiting for a retry.
+func (p *Publisher) Publish(ctx context.Context) error {
+	if p.Self == "" {
+		return fmt.Errorf("publisher has no self URL")
+	}
+	var errs []error
+	for _, hub := range p.Hubs {
+		if err := p.notify(ctx, hub); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notify sends the publish notification to the hub with retries.
+func (p *Publisher) notify(ctx context.Context, hub string) error {
+	delay := p.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	publishErr := &PublishError{Hub: hub}
+	for {
+		publishErr.Attempts++
+		retry, err := p.send(ctx, hub)
+		if err == nil {
+			return nil
+		}
+		publishErr.Err = err
+		if !retry || publishErr.Attempts > p.Retries {
+			return publishErr
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			publishErr.Err = ctx.Err()
+			return publishErr
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// send sends one publish notification to the hub
+// and reports if a failed notification should be retried.
+func (p *Publisher) send(ctx context.Context, hub string) (retry bool, err error) {
+	// hub.url is used by PubSubHubbub 0.4 hubs, hub.topic by some WebSub hubs
+	form := url.Values{
+		"hub.mode":  {"publish"},
+		"hub.url":   {p.Self},
+		"hub.topic": {p.Self},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("failed to create hub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("user-agent", "go-rss/1.0.0")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,117 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPublisherWrite tests writing feeds with hub and self links
+func TestPublisherWrite(t *testing.T) {
+	publisher := &Publisher{
+		Hubs: []string{"https://hub.example.com/"},
+		Self: "https://example.com/feed.xml",
+	}
+
+	channel := &Channel{
+		Title:     "Channel",
+		Link:      "https://example.com/",
+		AtomLinks: []Link{{Href: "https://old.example.com/feed", Rel: "self"}, {Href: "https://example.com/search", Rel: "search"}},
+		Item:      []Item{{Title: "Item"}},
+	}
+	var buf bytes.Buffer
+	if err := publisher.WriteRegular(&buf, channel); err != nil {
+		t.Fatalf("WriteRegular failed: %v", err)
+	}
+	parsed, err := ParseRegular(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+	hub := parsed.HubLinks()
+	if len(hub.Hubs) != 1 || hub.Hubs[0] != "https://hub.example.com/" || hub.Self != "https://example.com/feed.xml" {
+		t.Errorf("Unexpected hub links %+v", hub)
+	}
+	if parsed.Link != "https://example.com/" || len(parsed.AtomLinks) != 3 {
+		t.Errorf("Unexpected links %q, %+v", parsed.Link, parsed.AtomLinks)
+	}
+	if len(channel.AtomLinks) != 2 || channel.AtomLinks[0].Href != "https://old.example.com/feed" {
+		t.Error("Expected channel to be unchanged")
+	}
+
+	feed := &Feed{ID: "urn:feed", Title: "Feed", Updated: "2024-03-05T12:00:00Z", Entry: []Entry{{ID: "urn:1", Title: "Entry"}}}
+	buf.Reset()
+	if err := publisher.WriteAtom(&buf, feed); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+	parsedFeed, err := ParseAtom(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	if hub := parsedFeed.HubLinks(); len(hub.Hubs) != 1 || hub.Self != "https://example.com/feed.xml" {
+		t.Errorf("Unexpected hub links %+v", hub)
+	}
+}
+
+// TestPublisherPublish tests publish notifications with retries
+func TestPublisherPublish(t *testing.T) {
+	var attempts atomic.Int32
+	flakyHub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("hub.mode") != "publish" || r.PostForm.Get("hub.url") != "https://example.com/feed.xml" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if attempts.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer flakyHub.Close()
+
+	var rejected atomic.Int32
+	rejectingHub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejected.Add(1)
+		http.Error(w, "unknown topic", http.StatusBadRequest)
+	}))
+	defer rejectingHub.Close()
+
+	publisher := &Publisher{
+		Hubs:       []string{flakyHub.URL},
+		Self:       "https://example.com/feed.xml",
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+	}
+	if err := publisher.Publish(context.Background()); err != nil {
+		t.Errorf("Expected publish to succeed after retries, got %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+
+	// Client errors are not retried and reported per hub
+	attempts.Store(0)
+	publisher.Hubs = []string{rejectingHub.URL, flakyHub.URL}
+	publisher.Retries = 1
+	err := publisher.Publish(context.Background())
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) {
+		t.Fatalf("Expected PublishError, got %v", err)
+	}
+	if publishErr.Hub != rejectingHub.URL || publishErr.Attempts != 1 || rejected.Load() != 1 {
+		t.Errorf("Unexpected error %+v after %d requests", publishErr, rejected.Load())
+	}
+	if !strings.Contains(err.Error(), flakyHub.URL) || attempts.Load() != 2 {
+		t.Errorf("Expected flaky hub to fail after 2 attempts, got %v", err)
+	}
+
+	publisher.Self = ""
+	if err := publisher.Publish(context.Background()); err == nil {
+		t.Error("Expected error without self URL")
+	}
+}
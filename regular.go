@@ -3,92 +3,160 @@ package rss
 import (
 	"context"
 	"encoding/xml"
+	"fmt"
 	"io"
 	"net/http"
-
-	"github.com/paulrosania/go-charset/charset"
 )
 
 // Channel represents an RSS channel containing metadata and items.
 // It follows the RSS 2.0 specification structure.
+//
+// The JSON representation uses lower camel case keys with plural names
+// for lists, like "items" and "enclosures", omits empty fields
+// and normalizes dates to RFC 3339, see Date.MarshalJSON.
 type Channel struct {
 	// Title is the name of the channel
-	Title string `xml:"title"`
+	Title string `xml:"title" json:"title,omitempty"`
+
+	// AtomLinks are the atom:link elements of the channel, like the URL
+	// of the feed itself (rel="self") or its WebSub hub (rel="hub").
+	// The field is declared before Link so that atom:link elements
+	// don't overwrite the channel link during decoding.
+	AtomLinks []Link `xml:"http://www.w3.org/2005/Atom link,omitempty" json:"atomLinks,omitempty"`
 
 	// Link is the URL to the HTML website corresponding to the channel
-	Link string `xml:"link"`
+	Link string `xml:"link,omitempty" json:"link,omitempty"`
 
 	// Description is a phrase or sentence describing the channel
-	Description string `xml:"description"`
+	Description string `xml:"description,omitempty" json:"description,omitempty"`
 
 	// Language is the language the channel is written in
-	Language string `xml:"language"`
+	Language string `xml:"language,omitempty" json:"language,omitempty"`
 
 	// LastBuildDate indicates the last time the content of the channel changed
-	LastBuildDate Date `xml:"lastBuildDate"`
+	LastBuildDate Date `xml:"lastBuildDate,omitempty" json:"lastBuildDate,omitempty"`
+
+	// LastBuildTime is LastBuildDate resolved during parsing
+	LastBuildTime DateTime `xml:"-" json:"-"`
+
+	// Cloud is the rssCloud server notifying subscribers of updates, see RegisterCloud
+	Cloud *Cloud `xml:"cloud,omitempty" json:"cloud,omitempty"`
+
+	// ITunesImage is the artwork of a podcast. The field is declared before Image
+	// so that itunes:image elements don't overwrite the image during decoding.
+	ITunesImage *ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image,omitempty" json:"itunesImage,omitempty"`
+
+	// Image is the logo of the channel
+	Image *ChannelImage `xml:"image,omitempty" json:"image,omitempty"`
 
 	// Item is a slice of items in the channel
-	Item []Item `xml:"item"`
+	Item []Item `xml:"item,omitempty" json:"items,omitempty"`
+
+	// XMLBase is the xml:base attribute used to resolve relative URLs
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr,omitempty" json:"xmlBase,omitempty"`
 }
 
 // ItemEnclosure represents an enclosure element in an RSS item.
 // Enclosures are used to include media files with RSS items.
 type ItemEnclosure struct {
 	// URL is the location of the enclosed file
-	URL string `xml:"url,attr"`
+	URL string `xml:"url,attr" json:"url,omitempty"`
 
 	// Type is the MIME type of the enclosed file
-	Type string `xml:"type,attr"`
+	Type string `xml:"type,attr" json:"type,omitempty"`
+
+	// Length is the size of the enclosed file in bytes as found in the feed
+	Length string `xml:"length,attr" json:"length,omitempty"`
+}
+
+// ItemSource represents the source element of an RSS item,
+// the channel the item came from.
+type ItemSource struct {
+	// URL is the URL of the source channel's feed
+	URL string `xml:"url,attr,omitempty" json:"url,omitempty"`
+
+	// Title is the title of the source channel
+	Title string `xml:",chardata" json:"title,omitempty"`
 }
 
 // Item represents a single item in an RSS channel.
 // Each item typically represents a story, article, or other piece of content.
 type Item struct {
 	// Title is the title of the item
-	Title string `xml:"title"`
+	Title string `xml:"title" json:"title,omitempty"`
 
 	// Link is the URL of the item
-	Link string `xml:"link"`
+	Link string `xml:"link,omitempty" json:"link,omitempty"`
 
 	// Comments is the URL of a page for comments relating to the item
-	Comments string `xml:"comments"`
+	Comments string `xml:"comments,omitempty" json:"comments,omitempty"`
 
 	// PubDate is the publication date of the item
-	PubDate Date `xml:"pubDate"`
+	PubDate Date `xml:"pubDate,omitempty" json:"pubDate,omitempty"`
+
+	// PubTime is PubDate resolved during parsing
+	PubTime DateTime `xml:"-" json:"-"`
 
 	// GUID is a string that uniquely identifies the item
-	GUID string `xml:"guid"`
+	GUID string `xml:"guid,omitempty" json:"guid,omitempty"`
 
 	// Category is a list of categories that the item belongs to
-	Category []string `xml:"category"`
+	Category []string `xml:"category,omitempty" json:"categories,omitempty"`
 
 	// Enclosure is a list of media files associated with the item
-	Enclosure []ItemEnclosure `xml:"enclosure"`
+	Enclosure []ItemEnclosure `xml:"enclosure,omitempty" json:"enclosures,omitempty"`
 
 	// Description is a synopsis of the item
-	Description string `xml:"description"`
+	Description string `xml:"description,omitempty" json:"description,omitempty"`
 
 	// Author is the email address of the author of the item
-	Author string `xml:"author"`
+	Author string `xml:"author,omitempty" json:"author,omitempty"`
+
+	// Media are the media:content elements of the item (Media RSS).
+	// The field is declared before Content so that media:content
+	// elements don't overwrite the content during decoding.
+	Media []MediaContent `xml:"http://search.yahoo.com/mrss/ content,omitempty" json:"media,omitempty"`
+
+	// MediaThumbnail are the media:thumbnail elements of the item (Media RSS)
+	MediaThumbnail []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail,omitempty" json:"mediaThumbnails,omitempty"`
+
+	// ITunesImage is the artwork of a podcast episode
+	ITunesImage *ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image,omitempty" json:"itunesImage,omitempty"`
 
 	// Content is the full content of the item (if available)
-	Content string `xml:"content"`
+	Content string `xml:"content,omitempty" json:"content,omitempty"`
 
 	// FullText is the complete text content of the item
-	FullText string `xml:"full-text"`
+	FullText string `xml:"full-text,omitempty" json:"fullText,omitempty"`
+
+	// Source is the channel the item came from, set by aggregators like MergeRegular
+	Source *ItemSource `xml:"source,omitempty" json:"source,omitempty"`
+
+	// XMLBase is the xml:base attribute used to resolve relative URLs
+	XMLBase string `xml:"http://www.w3.org/XML/1998/namespace base,attr,omitempty" json:"xmlBase,omitempty"`
 }
 
 // ParseRegular parses an RSS 2.0 feed from an io.Reader.
 // It expects the reader to contain valid RSS XML.
 // The context is used for cancellation control during parsing.
 //
-// The function automatically handles character encoding detection and conversion
-// using the go-charset library, supporting various encodings commonly found
-// in RSS feeds.
+// The character encoding is detected from the byte order mark or the XML declaration
+// and converted to UTF-8, supporting various encodings commonly found in RSS feeds
+// like windows-1252, ISO-8859-x, KOI8-R, Shift_JIS, GB18030 and EUC-KR.
+//
+// The root element must be rss or an RDF element (RSS 1.0), otherwise an error
+// wrapping ErrWrongFormat is returned. Channels without items are valid,
+// use ParseRegularWithOptions with ParseOptions.RequireItems to reject them.
 //
 // Returns a Channel struct containing the parsed RSS data and any error that occurred.
 // The reader is not closed by this function; the caller is responsible for closing it.
 func ParseRegular(ctx context.Context, r io.Reader) (*Channel, error) {
+	return parseRegular(ctx, r, "", ParseOptions{})
+}
+
+// parseRegular parses an RSS feed using the charset of the contentType,
+// which may be empty, to decode the document.
+func parseRegular(ctx context.Context, r io.Reader, contentType string, options ParseOptions) (*Channel, error) {
 	// Check if context is cancelled before starting
 	select {
 	case <-ctx.Done():
@@ -96,15 +164,39 @@ func ParseRegular(ctx context.Context, r io.Reader) (*Channel, error) {
 	default:
 	}
 
-	xmlDecoder := xml.NewDecoder(r)
-	xmlDecoder.CharsetReader = charset.NewReader
+	channel, err := decodeRegular(newDecoder(r, contentType))
+	if err != nil {
+		return nil, err
+	}
+	if len(channel.Item) == 0 && options.RequireItems {
+		return nil, fmt.Errorf("%w: channel %q", ErrEmptyFeed, channel.Title)
+	}
+	channel.resolveDates(options)
+	return channel, nil
+}
+
+// decodeRegular decodes the channel of an RSS document
+// after checking its root element.
+func decodeRegular(xmlDecoder *xml.Decoder) (*Channel, error) {
+	start, err := rootStart(xmlDecoder)
+	if err != nil {
+		return nil, err
+	}
+	if !isRegularRoot(start.Name) {
+		return nil, &FormatError{Expected: FormatRSS, Root: start.Name}
+	}
 
 	var rss struct {
 		Channel Channel `xml:"channel"`
+
+		// Item contains the items of RSS 1.0 documents,
+		// which are siblings of the channel element
+		Item []Item `xml:"item"`
 	}
-	if err := xmlDecoder.Decode(&rss); err != nil {
+	if err := xmlDecoder.DecodeElement(&rss, &start); err != nil {
 		return nil, err
 	}
+	rss.Channel.Item = append(rss.Channel.Item, rss.Item...)
 	return &rss.Channel, nil
 }
 
@@ -112,13 +204,15 @@ func ParseRegular(ctx context.Context, r io.Reader) (*Channel, error) {
 // It expects the response body to contain valid RSS XML.
 // The context is used for cancellation control during parsing.
 //
-// The function automatically handles character encoding detection and conversion
-// using the go-charset library, supporting various encodings commonly found
-// in RSS feeds.
+// The character encoding is detected from the byte order mark, the charset of the
+// Content-Type header or the XML declaration, in this order of precedence (RFC 7303),
+// and converted to UTF-8.
+//
+// Like ParseRegular it returns errors wrapping ErrWrongFormat.
 //
 // Returns a Channel struct containing the parsed RSS data and any error that occurred.
 // The response body is automatically closed after parsing.
 func Regular(ctx context.Context, resp *http.Response) (*Channel, error) {
 	defer resp.Body.Close()
-	return ParseRegular(ctx, resp.Body)
+	return parseRegular(ctx, resp.Body, resp.Header.Get("Content-Type"), ParseOptions{})
 }
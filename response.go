@@ -0,0 +1,180 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrHTMLResponse is returned by ParseResponse if the server responded
+// with an HTML page instead of a feed, for example an error or login page.
+var ErrHTMLResponse = errors.New("response is an HTML page, not a feed")
+
+// ErrUnknownFormat is returned by ParseResponse if the response
+// is neither an RSS, Atom nor JSON feed.
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Format is the format of a feed document.
+type Format string
+
+const (
+	// FormatRSS is an RSS 2.0, 0.9x or RDF (RSS 1.0) feed, parsed as Channel
+	FormatRSS Format = "rss"
+
+	// FormatAtom is an Atom 1.0 feed, parsed as Feed
+	FormatAtom Format = "atom"
+
+	// FormatJSON is a JSON Feed, parsed as JSONFeed
+	FormatJSON Format = "json"
+)
+
+// ParsedFeed is the result of ParseResponse.
+// Depending on Format exactly one of Channel, Feed or JSONFeed is set.
+type ParsedFeed struct {
+	// Format is the detected format of the feed
+	Format Format `json:"format"`
+
+	// Channel is the parsed feed if Format is FormatRSS
+	Channel *Channel `json:"channel,omitempty"`
+
+	// Feed is the parsed feed if Format is FormatAtom
+	Feed *Feed `json:"feed,omitempty"`
+
+	// JSONFeed is the parsed feed if Format is FormatJSON
+	JSONFeed *JSONFeed `json:"jsonFeed,omitempty"`
+}
+
+// ParseResponse parses an RSS, Atom or JSON feed from an HTTP response,
+// detecting the format from the document and the Content-Type header.
+// The context is used for cancellation control during parsing.
+//
+// The root element of the document decides the format, because many servers
+// send feeds with a generic Content-Type like text/xml or even text/html.
+// The Content-Type (application/rss+xml, application/atom+xml,
+// application/feed+json) is used as hint for documents without
+// a recognizable root element.
+//
+// If the server returned an HTML page instead of a feed, an error wrapping
+// ErrHTMLResponse is returned. Documents of an unknown format result
+// in an error wrapping ErrUnknownFormat.
+//
+// The response body is automatically closed after parsing.
+func ParseResponse(ctx context.Context, resp *http.Response) (*ParsedFeed, error) {
+	return ParseResponseWithOptions(ctx, resp, ParseOptions{})
+}
+
+// ParseResponseWithOptions parses a feed from an HTTP response like ParseResponse
+// using the given options.
+// The response body is automatically closed after parsing.
+func ParseResponseWithOptions(ctx context.Context, resp *http.Response, options ParseOptions) (*ParsedFeed, error) {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	format, err := detectFormat(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w (HTTP %d, Content-Type %q)", err, resp.StatusCode, contentType)
+	}
+	var documentURL *url.URL
+	if resp.Request != nil {
+		documentURL = resp.Request.URL
+	}
+	return parseFormat(ctx, data, format, contentType, documentURL, options)
+}
+
+// parseFormat parses the feed document data of the detected format.
+// The documentURL may be nil if unknown.
+func parseFormat(ctx context.Context, data []byte, format Format, contentType string, documentURL *url.URL, options ParseOptions) (*ParsedFeed, error) {
+	switch format {
+	case FormatRSS:
+		channel, err := parseRegular(ctx, bytes.NewReader(data), contentType, options)
+		if err != nil {
+			return nil, err
+		}
+		return &ParsedFeed{Format: format, Channel: channel}, nil
+	case FormatAtom:
+		feed, err := parseAtom(ctx, bytes.NewReader(data), contentType, documentURL, options)
+		if err != nil {
+			return nil, err
+		}
+		return &ParsedFeed{Format: format, Feed: feed}, nil
+	default:
+		jsonFeed, err := ParseJSONFeed(ctx, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if len(jsonFeed.Items) == 0 && options.RequireItems {
+			return nil, fmt.Errorf("%w: feed %q", ErrEmptyFeed, jsonFeed.Title)
+		}
+		return &ParsedFeed{Format: format, JSONFeed: jsonFeed}, nil
+	}
+}
+
+// detectFormat detects the format of a feed document
+// from its content and the contentType, which may be empty.
+func detectFormat(data []byte, contentType string) (Format, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return FormatJSON, nil
+	}
+
+	root, isHTML := rootElement(data, contentType)
+	switch {
+	case root == "rss" || root == "RDF":
+		return FormatRSS, nil
+	case root == "feed":
+		return FormatAtom, nil
+	case isHTML:
+		return "", ErrHTMLResponse
+	}
+
+	// Unknown or missing root element, use the Content-Type as hint
+	switch mediaType {
+	case "application/rss+xml", "application/rdf+xml":
+		return FormatRSS, nil
+	case "application/atom+xml":
+		return FormatAtom, nil
+	case "application/feed+json", "application/json":
+		return FormatJSON, nil
+	case "text/html", "application/xhtml+xml":
+		return "", ErrHTMLResponse
+	}
+	return "", ErrUnknownFormat
+}
+
+// rootElement returns the local name of the root element of an XML document
+// and if the document is an HTML page. The name is empty if the document
+// has no root element that can be tokenized.
+func rootElement(data []byte, contentType string) (name string, isHTML bool) {
+	r, _ := utf8Reader(bytes.NewReader(data), contentType)
+	xmlDecoder := newUTF8Decoder(r, true)
+	xmlDecoder.Strict = false
+	for {
+		token, err := xmlDecoder.RawToken()
+		if err != nil {
+			return "", false
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t.Name.Local, strings.EqualFold(t.Name.Local, "html")
+		case xml.Directive:
+			if fields := strings.Fields(string(t)); len(fields) > 1 &&
+				strings.EqualFold(fields[0], "doctype") && strings.EqualFold(fields[1], "html") {
+				return "html", true
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testJSONFeed = `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"title": "JSON Example",
+	"home_page_url": "https://example.com/",
+	"items": [
+		{"id": 1, "url": "https://example.com/1", "title": "First", "content_html": "<p>Hello</p>"},
+		{"id": "2", "url": "https://example.com/2", "content_text": "World", "tags": ["go"]}
+	]
+}`
+
+func testResponse(contentType, body string) *http.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// TestParseResponse tests the format detection of ParseResponse
+func TestParseResponse(t *testing.T) {
+	readFile := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(testDataDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		format      Format
+	}{
+		{"RSS", "application/rss+xml; charset=UTF-8", readFile("wordpress.rss"), FormatRSS},
+		{"RSS as text/html", "text/html", readFile("techcrunch.rss"), FormatRSS},
+		{"Atom as RSS", "application/rss+xml", readFile("reddit.rss"), FormatAtom},
+		{"Atom", "application/atom+xml", `<feed xmlns="http://www.w3.org/2005/Atom"><title>Atom</title><entry><id>1</id></entry></feed>`, FormatAtom},
+		{"RDF", "", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"><channel><title>RDF</title></channel><item><title>RDF Item</title></item></rdf:RDF>`, FormatRSS},
+		{"JSON Feed", "application/feed+json", testJSONFeed, FormatJSON},
+		{"JSON Feed as text", "text/plain", testJSONFeed, FormatJSON},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := ParseResponse(context.Background(), testResponse(tc.contentType, tc.body))
+			if err != nil {
+				t.Fatalf("ParseResponse failed: %v", err)
+			}
+			if parsed.Format != tc.format {
+				t.Fatalf("Expected format %q, got %q", tc.format, parsed.Format)
+			}
+			switch {
+			case tc.format == FormatRSS && (parsed.Channel == nil || len(parsed.Channel.Item) == 0):
+				t.Error("Expected parsed channel with items")
+			case tc.format == FormatAtom && (parsed.Feed == nil || parsed.Feed.Title == ""):
+				t.Error("Expected parsed Atom feed")
+			case tc.format == FormatJSON && (parsed.JSONFeed == nil || len(parsed.JSONFeed.Items) != 2):
+				t.Error("Expected parsed JSON Feed with 2 items")
+			}
+		})
+	}
+}
+
+// TestParseResponseHTML tests that HTML pages are reported as ErrHTMLResponse
+func TestParseResponseHTML(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"doctype", "text/html; charset=utf-8", "<!DOCTYPE html>\n<html><head><title>404 Not Found</title></head><body><h1>Not Found</h1></body></html>"},
+		{"html root", "application/rss+xml", "<html><body>Please log in<br></body></html>"},
+		{"unparsable", "text/html", "Service <b>unavailable"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseResponse(context.Background(), testResponse(tc.contentType, tc.body))
+			if !errors.Is(err, ErrHTMLResponse) {
+				t.Errorf("Expected ErrHTMLResponse, got %v", err)
+			}
+		})
+	}
+
+	_, err := ParseResponse(context.Background(), testResponse("text/plain", "Hello"))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -65,7 +65,9 @@ func TestAllFeedsParse(t *testing.T) {
 
 		channel, err := Regular(ctx, resp)
 		if err != nil {
+			// Atom feeds are rejected with ErrWrongFormat
 			fmt.Println(err)
+			continue
 		}
 
 		for _, item := range channel.Item {
@@ -0,0 +1,317 @@
+package rss
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	htmlparser "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// removedWithContent are elements that are removed including their
+// content, because their content is not meant to be displayed as text.
+var removedWithContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"frame":    true,
+	"frameset": true,
+	"object":   true,
+	"embed":    true,
+	"applet":   true,
+	"noscript": true,
+	"template": true,
+	"form":     true,
+	"textarea": true,
+	"select":   true,
+	"button":   true,
+	"head":     true,
+	"title":    true,
+	"base":     true,
+	"link":     true,
+	"meta":     true,
+	"svg":      true,
+	"math":     true,
+}
+
+// SanitizePolicy is an allowlist of HTML elements, attributes and URL schemes
+// used to sanitize untrusted HTML from feeds.
+//
+// Elements that are not allowed are removed but their text content is kept,
+// except for elements like script, style, iframe or form which are removed
+// including their content. Event handler attributes (onclick, ...) are always removed.
+type SanitizePolicy struct {
+	// Elements maps allowed element names to their allowed attributes
+	Elements map[string][]string
+
+	// GlobalAttributes are attributes allowed on all allowed elements
+	GlobalAttributes []string
+
+	// URLSchemes are the allowed schemes of URLs in href, src and similar attributes.
+	// Attributes with other schemes like javascript: are removed.
+	URLSchemes []string
+
+	// AllowRelativeURLs allows URLs without scheme
+	AllowRelativeURLs bool
+
+	// IFrameHosts are the hosts of iframes that are kept, for example
+	// "www.youtube-nocookie.com". All other iframes are removed.
+	IFrameHosts []string
+
+	// RemoveTrackingPixels removes images with a width or height of
+	// at most one pixel and images loaded from TrackerHosts
+	RemoveTrackingPixels bool
+
+	// TrackerHosts are hosts of tracking images, including their subdomains
+	TrackerHosts []string
+
+	// LinkRel is set as rel attribute of all links if not empty,
+	// for example "nofollow noopener"
+	LinkRel string
+}
+
+// DefaultSanitizePolicy returns a policy allowing common formatting,
+// links, lists, tables, images and audio/video, suitable for
+// rendering feed content in a web page.
+func DefaultSanitizePolicy() *SanitizePolicy {
+	cellAttrs := []string{"colspan", "rowspan", "align"}
+	return &SanitizePolicy{
+		Elements: map[string][]string{
+			"a": {"href"}, "abbr": nil, "b": nil, "blockquote": {"cite"}, "br": nil,
+			"caption": nil, "cite": nil, "code": nil, "dd": nil, "del": nil, "details": nil,
+			"dfn": nil, "div": nil, "dl": nil, "dt": nil, "em": nil, "figcaption": nil,
+			"figure": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+			"hr": nil, "i": nil, "img": {"src", "srcset", "alt", "width", "height"},
+			"ins": nil, "kbd": nil, "li": nil, "mark": nil, "ol": {"start"}, "p": nil,
+			"picture": nil, "pre": nil, "q": {"cite"}, "s": nil, "samp": nil, "small": nil,
+			"span": nil, "strike": nil, "strong": nil, "sub": nil, "summary": nil, "sup": nil,
+			"table": nil, "tbody": nil, "td": cellAttrs, "tfoot": nil, "th": append(cellAttrs, "scope"),
+			"thead": nil, "time": {"datetime"}, "tr": nil, "u": nil, "ul": nil,
+			"audio": {"src", "controls"}, "video": {"src", "poster", "controls", "width", "height"},
+			"source": {"src", "srcset", "type", "media"},
+		},
+		GlobalAttributes:     []string{"title", "lang", "dir"},
+		URLSchemes:           []string{"http", "https", "mailto"},
+		AllowRelativeURLs:    true,
+		RemoveTrackingPixels: true,
+		TrackerHosts: []string{
+			"feeds.feedburner.com",
+			"feedproxy.google.com",
+			"pixel.wp.com",
+			"stats.wordpress.com",
+		},
+		LinkRel: "nofollow noopener",
+	}
+}
+
+// Sanitize returns the HTML content with everything removed that is
+// not allowed by the policy. The result is well-formed HTML.
+func (p *SanitizePolicy) Sanitize(content string) string {
+	if !strings.ContainsAny(content, "<&") {
+		return content
+	}
+
+	body := &htmlparser.Node{Type: htmlparser.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := htmlparser.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+	p.sanitizeChildren(body)
+	for node := body.FirstChild; node != nil; node = node.NextSibling {
+		if err := htmlparser.Render(&b, node); err != nil {
+			return ""
+		}
+	}
+	return b.String()
+}
+
+// sanitizeChildren sanitizes the children of parent in place.
+func (p *SanitizePolicy) sanitizeChildren(parent *htmlparser.Node) {
+	for node := parent.FirstChild; node != nil; {
+		next := node.NextSibling
+		switch node.Type {
+		case htmlparser.TextNode:
+			// Text is escaped when rendered
+		case htmlparser.ElementNode:
+			p.sanitizeElement(parent, node)
+		default:
+			// Comments, doctypes, ...
+			parent.RemoveChild(node)
+		}
+		node = next
+	}
+}
+
+// sanitizeElement sanitizes the element node which is a child of parent.
+// The node is removed, replaced by its children or cleaned in place.
+func (p *SanitizePolicy) sanitizeElement(parent, node *htmlparser.Node) {
+	name := node.Data
+	if name == "iframe" && p.allowedIFrame(node) {
+		node.Attr = p.filterAttrs(node, []string{"src", "width", "height", "allowfullscreen"})
+		removeChildren(node)
+		return
+	}
+	if removedWithContent[name] || node.Namespace != "" {
+		parent.RemoveChild(node)
+		return
+	}
+	allowedAttrs, allowed := p.Elements[name]
+	if !allowed {
+		// Keep the content of unknown elements
+		p.sanitizeChildren(node)
+		for child := node.FirstChild; child != nil; {
+			next := child.NextSibling
+			node.RemoveChild(child)
+			parent.InsertBefore(child, node)
+			child = next
+		}
+		parent.RemoveChild(node)
+		return
+	}
+	if name == "img" && p.RemoveTrackingPixels && p.isTrackingPixel(node) {
+		parent.RemoveChild(node)
+		return
+	}
+
+	node.Attr = p.filterAttrs(node, allowedAttrs)
+	if name == "a" && p.LinkRel != "" {
+		node.Attr = append(node.Attr, htmlparser.Attribute{Key: "rel", Val: p.LinkRel})
+	}
+	p.sanitizeChildren(node)
+}
+
+// filterAttrs returns the allowed attributes of the node with safe URLs.
+func (p *SanitizePolicy) filterAttrs(node *htmlparser.Node, allowedAttrs []string) []htmlparser.Attribute {
+	var attrs []htmlparser.Attribute
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || strings.HasPrefix(key, "on") {
+			continue
+		}
+		if !slices.Contains(allowedAttrs, key) && !slices.Contains(p.GlobalAttributes, key) {
+			continue
+		}
+		switch key {
+		case "href", "src", "cite", "poster":
+			if !p.allowedURL(attr.Val) {
+				continue
+			}
+		case "srcset":
+			if !p.allowedSrcset(attr.Val) {
+				continue
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// allowedURL checks the scheme of a URL attribute value.
+func (p *SanitizePolicy) allowedURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		// Includes URLs with control characters like "java\tscript:"
+		return false
+	}
+	if u.Scheme == "" {
+		return p.AllowRelativeURLs && !strings.Contains(u.Path, ":")
+	}
+	return slices.Contains(p.URLSchemes, strings.ToLower(u.Scheme))
+}
+
+func (p *SanitizePolicy) allowedSrcset(srcset string) bool {
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !p.allowedURL(fields[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *SanitizePolicy) allowedIFrame(node *htmlparser.Node) bool {
+	src := nodeAttr(node, "src")
+	if src == "" || !p.allowedURL(src) {
+		return false
+	}
+	u, err := url.Parse(src)
+	return err == nil && slices.Contains(p.IFrameHosts, strings.ToLower(u.Hostname()))
+}
+
+// isTrackingPixel reports if an img node is a tiny or hidden image
+// or is loaded from a tracker host.
+func (p *SanitizePolicy) isTrackingPixel(node *htmlparser.Node) bool {
+	for _, dimension := range []string{"width", "height"} {
+		value := strings.TrimSuffix(strings.TrimSpace(nodeAttr(node, dimension)), "px")
+		if size, err := strconv.Atoi(value); err == nil && size <= 1 {
+			return true
+		}
+	}
+	style := strings.ReplaceAll(strings.ToLower(nodeAttr(node, "style")), " ", "")
+	if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+		return true
+	}
+	u, err := url.Parse(strings.TrimSpace(nodeAttr(node, "src")))
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, tracker := range p.TrackerHosts {
+		if host == tracker || strings.HasSuffix(host, "."+tracker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sanitize sanitizes the HTML of the item's description, content and full text in place.
+func (item *Item) Sanitize(policy *SanitizePolicy) {
+	item.Description = policy.Sanitize(item.Description)
+	item.Content = policy.Sanitize(item.Content)
+	item.FullText = policy.Sanitize(item.FullText)
+}
+
+// Sanitize sanitizes the HTML of all items in place, see Item.Sanitize.
+func (c *Channel) Sanitize(policy *SanitizePolicy) {
+	for i := range c.Item {
+		c.Item[i].Sanitize(policy)
+	}
+}
+
+// Sanitize sanitizes the HTML and XHTML body of the content in place.
+// Text content is left as is because it is escaped by Content.HTML.
+func (c *Content) Sanitize(policy *SanitizePolicy) {
+	if c.Type == "html" || c.Type == "xhtml" {
+		c.Body = policy.Sanitize(c.Body)
+	}
+}
+
+// Sanitize sanitizes the summary and content of all entries in place.
+func (f *Feed) Sanitize(policy *SanitizePolicy) {
+	for i := range f.Entry {
+		f.Entry[i].Summary.Sanitize(policy)
+		f.Entry[i].Content.Sanitize(policy)
+	}
+}
+
+func nodeAttr(node *htmlparser.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func removeChildren(node *htmlparser.Node) {
+	for node.FirstChild != nil {
+		node.RemoveChild(node.FirstChild)
+	}
+}
@@ -0,0 +1,73 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSanitize tests removal of dangerous markup with the default policy
+func TestSanitize(t *testing.T) {
+	policy := DefaultSanitizePolicy()
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text", "Just text", "Just text"},
+		{"entities", "Fish & Chips", "Fish &amp; Chips"},
+		{"script", `<p>Hi<script>alert(1)</script></p>`, `<p>Hi</p>`},
+		{"event handler", `<p onclick="alert(1)" title="t">Hi</p>`, `<p title="t">Hi</p>`},
+		{"javascript URL", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"obfuscated javascript URL", `<a href="java&#09;script:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"allowed link", `<a href="https://example.com/" target="_blank">x</a>`, `<a href="https://example.com/" rel="nofollow noopener">x</a>`},
+		{"iframe", `<p>a</p><iframe src="https://evil.example.com/"></iframe>`, `<p>a</p>`},
+		{"tracking pixel", `<img src="https://example.com/t.gif" width="1" height="1"><img src="a.png" alt="a">`, `<img src="a.png" alt="a"/>`},
+		{"tracker host", `<img src="https://pixel.wp.com/g.gif">`, ``},
+		{"unknown element", `<custom><b>bold</b></custom>`, `<b>bold</b>`},
+		{"unclosed tags", `<p><b>bold`, `<p><b>bold</b></p>`},
+		{"comment", `a<!-- secret -->b`, `ab`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := policy.Sanitize(tc.input); got != tc.expected {
+				t.Errorf("Sanitize(%q) = %q, expected %q", tc.input, got, tc.expected)
+			}
+		})
+	}
+}
+
+// TestSanitizeIFrameHosts tests that iframes from allowed hosts are kept
+func TestSanitizeIFrameHosts(t *testing.T) {
+	policy := DefaultSanitizePolicy()
+	policy.IFrameHosts = []string{"www.youtube-nocookie.com"}
+
+	got := policy.Sanitize(`<iframe src="https://www.youtube-nocookie.com/embed/x" onload="x()" width="560">fallback</iframe>`)
+	expected := `<iframe src="https://www.youtube-nocookie.com/embed/x" width="560"></iframe>`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+// TestChannelSanitize tests sanitizing parsed items and entries
+func TestChannelSanitize(t *testing.T) {
+	channel := &Channel{Item: []Item{{
+		Description: `<p>Hi<script>x()</script></p>`,
+		Content:     `<img src="x" onerror="x()">`,
+	}}}
+	channel.Sanitize(DefaultSanitizePolicy())
+	if channel.Item[0].Description != "<p>Hi</p>" || strings.Contains(channel.Item[0].Content, "onerror") {
+		t.Errorf("Unexpected sanitized item: %+v", channel.Item[0])
+	}
+
+	feed := &Feed{Entry: []Entry{{
+		Summary: Content{Type: "text", Body: "<script> is text here"},
+		Content: Content{Type: "html", Body: `<p>Hi<script>x()</script></p>`},
+	}}}
+	feed.Sanitize(DefaultSanitizePolicy())
+	if feed.Entry[0].Summary.Body != "<script> is text here" {
+		t.Errorf("Expected text summary to be unchanged, got %q", feed.Entry[0].Summary.Body)
+	}
+	if feed.Entry[0].Content.Body != "<p>Hi</p>" {
+		t.Errorf("Expected sanitized content, got %q", feed.Entry[0].Content.Body)
+	}
+}
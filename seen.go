@@ -0,0 +1,409 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SeenStore defines the interface for recording the items of feeds
+// that were already processed, so that only new items are processed
+// after a restart. Feeds are identified by a string like their URL,
+// items by keys like their GUID, see UnseenItems.
+// Implementations must be safe for concurrent use.
+type SeenStore interface {
+	// Seen returns which of the keys of the feed are recorded.
+	Seen(ctx context.Context, feed string, keys []string) (map[string]bool, error)
+
+	// Mark records the keys of the feed as seen.
+	Mark(ctx context.Context, feed string, keys []string) error
+
+	// Prune removes records that are older than the TTL of the store.
+	Prune(ctx context.Context) error
+}
+
+// seenRecords are the times items were seen, by feed and key.
+type seenRecords map[string]map[string]time.Time
+
+// seen returns which of the keys of the feed were seen after expired.
+func (r seenRecords) seen(feed string, keys []string, expired time.Time) map[string]bool {
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if t, ok := r[feed][key]; ok && t.After(expired) {
+			seen[key] = true
+		}
+	}
+	return seen
+}
+
+func (r seenRecords) mark(feed, key string, t time.Time) {
+	if r[feed] == nil {
+		r[feed] = make(map[string]time.Time)
+	}
+	r[feed][key] = t
+}
+
+// prune removes the records seen before or at expired.
+func (r seenRecords) prune(expired time.Time) {
+	for feed, keys := range r {
+		for key, t := range keys {
+			if !t.After(expired) {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(r, feed)
+		}
+	}
+}
+
+// write writes the records as JSON lines to w.
+func (r seenRecords) write(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	for feed, keys := range r {
+		for key, t := range keys {
+			if err := encoder.Encode(seenLine{Feed: feed, Key: key, Seen: t}); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// MemorySeenStore is an in-memory SeenStore.
+type MemorySeenStore struct {
+	// Now returns the current time, time.Now if nil
+	Now func() time.Time
+
+	mtx     sync.Mutex
+	ttl     time.Duration
+	records seenRecords
+}
+
+// NewMemorySeenStore returns a MemorySeenStore that forgets
+// items seen longer than ttl ago. A ttl <= 0 means items are never forgotten.
+func NewMemorySeenStore(ttl time.Duration) *MemorySeenStore {
+	return &MemorySeenStore{ttl: ttl, records: make(seenRecords)}
+}
+
+// Seen implements the SeenStore interface.
+func (s *MemorySeenStore) Seen(ctx context.Context, feed string, keys []string) (map[string]bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.records.seen(feed, keys, expiredBefore(currentTime(s.Now), s.ttl)), nil
+}
+
+// Mark implements the SeenStore interface.
+func (s *MemorySeenStore) Mark(ctx context.Context, feed string, keys []string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	t := currentTime(s.Now)
+	for _, key := range keys {
+		s.records.mark(feed, key, t)
+	}
+	return nil
+}
+
+// Prune implements the SeenStore interface.
+func (s *MemorySeenStore) Prune(ctx context.Context) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.records.prune(expiredBefore(currentTime(s.Now), s.ttl))
+	return nil
+}
+
+// FileSeenStore is a SeenStore persisting its records as JSON lines in a file.
+// Records are appended to the file when items are marked,
+// Prune rewrites the file without the expired records.
+// All records are also held in memory.
+type FileSeenStore struct {
+	// Now returns the current time, time.Now if nil
+	Now func() time.Time
+
+	mtx     sync.Mutex
+	path    string
+	ttl     time.Duration
+	file    *os.File
+	records seenRecords
+
+	// truncated is true if the last line of the file has no newline
+	truncated bool
+}
+
+// seenLine is a line of the file of a FileSeenStore.
+type seenLine struct {
+	Feed string    `json:"feed"`
+	Key  string    `json:"key"`
+	Seen time.Time `json:"seen"`
+}
+
+// NewFileSeenStore returns a FileSeenStore with the records of the file at path,
+// which is created if it does not exist. Items seen longer than ttl ago are
+// forgotten, a ttl <= 0 means never. Lines that can't be decoded, like a line
+// truncated by a crash, are skipped.
+// The store must be closed to release the file.
+func NewFileSeenStore(path string, ttl time.Duration) (*FileSeenStore, error) {
+	s := &FileSeenStore{path: path, ttl: ttl, records: make(seenRecords)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the records of the file.
+func (s *FileSeenStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read seen store: %w", err)
+	}
+	for _, data := range bytes.Split(data, []byte{'\n'}) {
+		var line seenLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			continue
+		}
+		s.records.mark(line.Feed, line.Key, line.Seen)
+	}
+	// Terminate a truncated last line before appending
+	s.truncated = len(data) > 0 && data[len(data)-1] != '\n'
+	return nil
+}
+
+// open opens the file for appending records.
+func (s *FileSeenStore) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open seen store: %w", err)
+	}
+	s.file = file
+	return nil
+}
+
+// Seen implements the SeenStore interface.
+func (s *FileSeenStore) Seen(ctx context.Context, feed string, keys []string) (map[string]bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.records.seen(feed, keys, expiredBefore(currentTime(s.Now), s.ttl)), nil
+}
+
+// Mark implements the SeenStore interface.
+func (s *FileSeenStore) Mark(ctx context.Context, feed string, keys []string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.file == nil {
+		return fs.ErrClosed
+	}
+	t := currentTime(s.Now)
+	var lines []byte
+	if s.truncated {
+		lines = append(lines, '\n')
+	}
+	for _, key := range keys {
+		line, err := json.Marshal(seenLine{Feed: feed, Key: key, Seen: t})
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	if _, err := s.file.Write(lines); err != nil {
+		return fmt.Errorf("failed to write seen store: %w", err)
+	}
+	s.truncated = false
+	for _, key := range keys {
+		s.records.mark(feed, key, t)
+	}
+	return nil
+}
+
+// Prune implements the SeenStore interface.
+// The file is rewritten to a temporary file first and then renamed,
+// so the records survive a crash during pruning.
+func (s *FileSeenStore) Prune(ctx context.Context) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.file == nil {
+		return fs.ErrClosed
+	}
+	s.records.prune(expiredBefore(currentTime(s.Now), s.ttl))
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to prune seen store: %w", err)
+	}
+	err = s.records.write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to prune seen store: %w", err)
+	}
+
+	// Continue appending to the new file
+	s.file.Close()
+	s.truncated = false
+	return s.open()
+}
+
+// Close closes the file of the store.
+func (s *FileSeenStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// UnseenItems returns the items of the channel that are not recorded
+// in the store for the feed, in the order of the channel.
+// Items are identified by key, nil means DefaultItemKey,
+// see ItemKeyFunc for keys with options.
+// Repeated items of the channel are returned once.
+//
+// The items are not recorded, call MarkItemsSeen with the same key after processing them.
+func UnseenItems(ctx context.Context, store SeenStore, feed string, channel *Channel, key func(item *Item) string) ([]Item, error) {
+	if key == nil {
+		key = DefaultItemKey
+	}
+	keys := make([]string, len(channel.Item))
+	for i := range channel.Item {
+		keys[i] = key(&channel.Item[i])
+	}
+	unseen, err := unseenIndices(ctx, store, feed, keys)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(unseen))
+	for _, i := range unseen {
+		items = append(items, channel.Item[i])
+	}
+	return items, nil
+}
+
+// MarkItemsSeen records the items in the store for the feed by key,
+// nil means DefaultItemKey, see UnseenItems.
+func MarkItemsSeen(ctx context.Context, store SeenStore, feed string, items []Item, key func(item *Item) string) error {
+	if key == nil {
+		key = DefaultItemKey
+	}
+	keys := make([]string, len(items))
+	for i := range items {
+		keys[i] = key(&items[i])
+	}
+	return store.Mark(ctx, feed, keys)
+}
+
+// UnseenEntries returns the entries of the Atom feed that are not recorded
+// in the store for the feed, in the order of the feed.
+// Entries are identified by key, nil means DefaultEntryKey,
+// see EntryKeyFunc for keys with options.
+// Repeated entries of the feed are returned once.
+//
+// The entries are not recorded, call MarkEntriesSeen with the same key after processing them.
+func UnseenEntries(ctx context.Context, store SeenStore, feed string, atom *Feed, key func(entry *Entry) string) ([]Entry, error) {
+	if key == nil {
+		key = DefaultEntryKey
+	}
+	keys := make([]string, len(atom.Entry))
+	for i := range atom.Entry {
+		keys[i] = key(&atom.Entry[i])
+	}
+	unseen, err := unseenIndices(ctx, store, feed, keys)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(unseen))
+	for _, i := range unseen {
+		entries = append(entries, atom.Entry[i])
+	}
+	return entries, nil
+}
+
+// MarkEntriesSeen records the entries in the store for the feed by key,
+// nil means DefaultEntryKey, see UnseenEntries.
+func MarkEntriesSeen(ctx context.Context, store SeenStore, feed string, entries []Entry, key func(entry *Entry) string) error {
+	if key == nil {
+		key = DefaultEntryKey
+	}
+	keys := make([]string, len(entries))
+	for i := range entries {
+		keys[i] = key(&entries[i])
+	}
+	return store.Mark(ctx, feed, keys)
+}
+
+// unseenIndices returns the indices of the first occurrences
+// of the keys that are not recorded in the store.
+func unseenIndices(ctx context.Context, store SeenStore, feed string, keys []string) ([]int, error) {
+	seen, err := store.Seen(ctx, feed, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seen store: %w", err)
+	}
+	var unseen []int
+	returned := make(map[string]bool)
+	for i, key := range keys {
+		if seen[key] || returned[key] {
+			continue
+		}
+		returned[key] = true
+		unseen = append(unseen, i)
+	}
+	return unseen, nil
+}
+
+// contentHash returns a SHA-256 hash of the trimmed parts prefixed with "sha256:".
+func contentHash(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(strings.TrimSpace(part)))
+		hash.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// expiredBefore returns the time before which records are expired,
+// or the zero time if they never expire.
+func expiredBefore(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-ttl)
+}
+
+func currentTime(nowFunc func() time.Time) time.Time {
+	if nowFunc == nil {
+		return time.Now()
+	}
+	return nowFunc()
+}
@@ -0,0 +1,196 @@
+package rss
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSeenRSS = `<rss version="2.0"><channel>
+	<title>Seen</title>
+	<item><title>First</title><guid>1</guid></item>
+	<item><title>Second</title><guid>2</guid></item>
+	<item><title>Without GUID</title><link>https://example.com/3</link></item>
+	<item><title>Repeated</title><guid>1</guid></item>
+</channel></rss>`
+
+// testSeenStore tests the behavior shared by all SeenStore implementations
+func testSeenStore(t *testing.T, store SeenStore, clock *time.Time) {
+	ctx := context.Background()
+	channel, err := ParseRegular(ctx, strings.NewReader(testSeenRSS))
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+
+	unseen, err := UnseenItems(ctx, store, "feed", channel, nil)
+	if err != nil {
+		t.Fatalf("UnseenItems failed: %v", err)
+	}
+	if titles := itemTitles(&Channel{Item: unseen}); titles != "First, Second, Without GUID" {
+		t.Errorf("Unexpected unseen items %q", titles)
+	}
+	if err := MarkItemsSeen(ctx, store, "feed", unseen[:2], nil); err != nil {
+		t.Fatalf("MarkItemsSeen failed: %v", err)
+	}
+
+	*clock = clock.Add(time.Hour)
+	if err := MarkItemsSeen(ctx, store, "feed", channel.Item[2:3], nil); err != nil {
+		t.Fatalf("MarkItemsSeen failed: %v", err)
+	}
+	unseen, err = UnseenItems(ctx, store, "feed", channel, nil)
+	if err != nil {
+		t.Fatalf("UnseenItems failed: %v", err)
+	}
+	if len(unseen) != 0 {
+		t.Errorf("Expected all items to be seen, got %q", itemTitles(&Channel{Item: unseen}))
+	}
+
+	// Items are recorded per feed
+	unseen, err = UnseenItems(ctx, store, "other feed", channel, nil)
+	if err != nil {
+		t.Fatalf("UnseenItems failed: %v", err)
+	}
+	if len(unseen) != 3 {
+		t.Errorf("Expected 3 unseen items for other feed, got %d", len(unseen))
+	}
+
+	// Items seen longer than the TTL ago are forgotten
+	*clock = clock.Add(90 * time.Minute)
+	if err := store.Prune(ctx); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	unseen, err = UnseenItems(ctx, store, "feed", channel, nil)
+	if err != nil {
+		t.Fatalf("UnseenItems failed: %v", err)
+	}
+	if titles := itemTitles(&Channel{Item: unseen}); titles != "First, Second" {
+		t.Errorf("Expected expired items to be unseen, got %q", titles)
+	}
+}
+
+// TestMemorySeenStore tests the in-memory SeenStore
+func TestMemorySeenStore(t *testing.T) {
+	clock := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	store := NewMemorySeenStore(2 * time.Hour)
+	store.Now = func() time.Time { return clock }
+	testSeenStore(t, store, &clock)
+}
+
+// TestFileSeenStore tests the file-based SeenStore and its persistence
+func TestFileSeenStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seen.jsonl")
+	clock := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileSeenStore(path, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileSeenStore failed: %v", err)
+	}
+	store.Now = func() time.Time { return clock }
+	testSeenStore(t, store, &clock)
+	if err := store.Mark(ctx, "atom", []string{"urn:1"}); err != nil {
+		t.Fatalf("Mark failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Simulate a crash while writing a line
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"feed":"atom","key":"urn:`)
+	file.Close()
+
+	store, err = NewFileSeenStore(path, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileSeenStore failed: %v", err)
+	}
+	defer store.Close()
+	store.Now = func() time.Time { return clock }
+
+	feed, err := ParseAtom(ctx, strings.NewReader(`<feed xmlns="http://www.w3.org/2005/Atom">
+		<id>urn:feed</id><title>Feed</title><updated>2024-03-05T12:00:00Z</updated>
+		<entry><id>urn:1</id><title>Seen</title><updated>2024-03-05T12:00:00Z</updated></entry>
+		<entry><id>urn:2</id><title>New</title><updated>2024-03-05T12:00:00Z</updated></entry>
+		<entry><title>Without ID</title><updated>2024-03-05T12:00:00Z</updated></entry>
+	</feed>`))
+	if err != nil {
+		t.Fatalf("ParseAtom failed: %v", err)
+	}
+	entries, err := UnseenEntries(ctx, store, "atom", feed, nil)
+	if err != nil {
+		t.Fatalf("UnseenEntries failed: %v", err)
+	}
+	if titles := entryTitles(&Feed{Entry: entries}); titles != "New, Without ID" {
+		t.Errorf("Unexpected unseen entries %q", titles)
+	}
+	if err := MarkEntriesSeen(ctx, store, "atom", entries, nil); err != nil {
+		t.Fatalf("MarkEntriesSeen failed: %v", err)
+	}
+
+	// The records after the truncated line are readable
+	reopened, err := NewFileSeenStore(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileSeenStore failed: %v", err)
+	}
+	defer reopened.Close()
+	entries, err = UnseenEntries(ctx, reopened, "atom", feed, nil)
+	if err != nil {
+		t.Fatalf("UnseenEntries failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected all entries to be seen after reopening, got %q", entryTitles(&Feed{Entry: entries}))
+	}
+	withoutGUID := (&Item{Link: "https://example.com/3"}).Key()
+	seen, err := reopened.Seen(ctx, "feed", []string{"1", withoutGUID})
+	if err != nil || seen["1"] || !seen[withoutGUID] {
+		t.Errorf("Expected pruned file to keep only unexpired records, got %v, %v", seen, err)
+	}
+}
+
+// TestUnseenItemsWithKey tests seen-tracking with normalized URLs as keys
+func TestUnseenItemsWithKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySeenStore(0)
+	key := ItemKeyFunc(KeyOptions{NormalizeURLs: true})
+
+	first := &Channel{Item: []Item{{Title: "A", Link: "https://example.com/a?utm_source=rss"}}}
+	unseen, err := UnseenItems(ctx, store, "feed", first, key)
+	if err != nil || len(unseen) != 1 {
+		t.Fatalf("Expected one unseen item, got %v, %v", unseen, err)
+	}
+	if err := MarkItemsSeen(ctx, store, "feed", unseen, key); err != nil {
+		t.Fatalf("MarkItemsSeen failed: %v", err)
+	}
+
+	// The same link with other tracking parameters is not new
+	second := &Channel{Item: []Item{
+		{Title: "A", Link: "http://example.com/a/?utm_source=twitter"},
+		{Title: "B", Link: "https://example.com/b"},
+	}}
+	unseen, err = UnseenItems(ctx, store, "feed", second, key)
+	if err != nil {
+		t.Fatalf("UnseenItems failed: %v", err)
+	}
+	if got := itemTitles(&Channel{Item: unseen}); got != "B" {
+		t.Errorf("Expected only B to be unseen, got %q", got)
+	}
+	if unseen, _ := UnseenItems(ctx, store, "feed", second, nil); len(unseen) != 2 {
+		t.Errorf("Expected default keys to differ, got %d unseen items", len(unseen))
+	}
+
+	entries := &Feed{Entry: []Entry{{Title: "A", Link: []Link{{Href: "https://example.com/a#comments"}}}}}
+	entryKey := EntryKeyFunc(KeyOptions{NormalizeURLs: true})
+	if err := MarkEntriesSeen(ctx, store, "atom", entries.Entry, entryKey); err != nil {
+		t.Fatalf("MarkEntriesSeen failed: %v", err)
+	}
+	entries.Entry[0].Link[0].Href = "https://example.com/a"
+	if unseen, err := UnseenEntries(ctx, store, "atom", entries, entryKey); err != nil || len(unseen) != 0 {
+		t.Errorf("Expected no unseen entries, got %v, %v", unseen, err)
+	}
+}
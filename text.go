@@ -0,0 +1,446 @@
+package rss
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	htmlparser "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// ellipsis is appended to shortened summaries.
+	ellipsis = "…"
+
+	// zeroWidthJoiner joins characters like emoji to one displayed character.
+	zeroWidthJoiner = '\u200d'
+)
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// HTMLToText converts HTML content to readable plain text.
+// Paragraphs are separated by blank lines, list items are prefixed
+// with "- " or their number, and link targets are appended
+// in parentheses after the link text.
+func HTMLToText(content string) string {
+	return renderText(content, false)
+}
+
+// HTMLToMarkdown converts HTML content to CommonMark.
+// Formatting without Markdown equivalent is dropped, keeping the text.
+func HTMLToMarkdown(content string) string {
+	return renderText(content, true)
+}
+
+// Summarize converts HTML content to a single line of plain text
+// of at most maxLen characters. Longer text is shortened at a word
+// boundary and ends with an ellipsis. Text without space before the limit
+// is shortened at the last character boundary, keeping combining marks
+// with their base character. Because the text is decoded,
+// the summary never contains broken HTML entities.
+func Summarize(content string, maxLen int) string {
+	text := strings.Join(strings.Fields(HTMLToText(content)), " ")
+	if maxLen <= 0 || utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	runes := []rune(text)
+	n := max(maxLen-utf8.RuneCountInString(ellipsis), 0)
+	cut := string(runes[:n])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	} else {
+		cut = string(runes[:characterBoundary(runes, n)])
+	}
+	return strings.TrimRight(cut, " ,;:-") + ellipsis
+}
+
+// characterBoundary returns the largest n up to limit at which the runes
+// can be cut without separating combining marks from their base character
+// or splitting characters joined with a zero width joiner.
+func characterBoundary(runes []rune, limit int) int {
+	n := limit
+	for n > 0 && n < len(runes) && (unicode.Is(unicode.M, runes[n]) || runes[n] == zeroWidthJoiner || runes[n-1] == zeroWidthJoiner) {
+		n--
+	}
+	return n
+}
+
+// Text returns the content of the item as plain text,
+// using Content if available and Description otherwise.
+func (item *Item) Text() string {
+	return HTMLToText(item.html())
+}
+
+// Markdown returns the content of the item as CommonMark,
+// using Content if available and Description otherwise.
+func (item *Item) Markdown() string {
+	return HTMLToMarkdown(item.html())
+}
+
+// Summary returns a plain text summary of the item's description
+// of at most maxLen characters, see Summarize.
+// Content is used if the item has no description.
+func (item *Item) Summary(maxLen int) string {
+	if strings.TrimSpace(item.Description) != "" {
+		return Summarize(item.Description, maxLen)
+	}
+	return Summarize(item.Content, maxLen)
+}
+
+func (item *Item) html() string {
+	if strings.TrimSpace(item.Content) != "" {
+		return item.Content
+	}
+	return item.Description
+}
+
+// Text returns the content of the entry as plain text,
+// using Content if available and Summary otherwise.
+func (e *Entry) Text() string {
+	return HTMLToText(e.html())
+}
+
+// Markdown returns the content of the entry as CommonMark,
+// using Content if available and Summary otherwise.
+func (e *Entry) Markdown() string {
+	return HTMLToMarkdown(e.html())
+}
+
+// SummaryText returns a plain text summary of the entry's summary
+// of at most maxLen characters, see Summarize.
+// Content is used if the entry has no summary.
+func (e *Entry) SummaryText(maxLen int) string {
+	if strings.TrimSpace(e.Summary.Body) != "" {
+		return Summarize(e.Summary.HTML(), maxLen)
+	}
+	return Summarize(e.Content.HTML(), maxLen)
+}
+
+func (e *Entry) html() string {
+	if strings.TrimSpace(e.Content.Body) != "" {
+		return e.Content.HTML()
+	}
+	return e.Summary.HTML()
+}
+
+// textRenderer writes the text of an HTML tree as plain text or Markdown.
+type textRenderer struct {
+	b        strings.Builder
+	markdown bool
+
+	// newlines is the number of line breaks to write before the next text
+	newlines int
+	// breakPrefix are the prefixes in effect when the line breaks were requested
+	breakPrefix string
+	// prefixes are written at the start of every line, for block quotes and list items
+	prefixes []string
+	// marker is the list item marker written before the next text
+	marker string
+	// lists holds the item counters of the open lists, 0 for unordered lists
+	lists []int
+	// pre counts the open pre elements
+	pre int
+	// lineStart is true if nothing but prefixes was written on the current line
+	lineStart bool
+}
+
+func renderText(content string, markdown bool) string {
+	if !strings.ContainsAny(content, "<&") {
+		return strings.TrimSpace(content)
+	}
+	body := &htmlparser.Node{Type: htmlparser.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := htmlparser.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return strings.TrimSpace(content)
+	}
+	r := &textRenderer{markdown: markdown}
+	for _, node := range nodes {
+		r.node(node)
+	}
+	lines := strings.Split(r.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (r *textRenderer) node(n *htmlparser.Node) {
+	switch n.Type {
+	case htmlparser.TextNode:
+		r.text(n.Data)
+		return
+	case htmlparser.ElementNode:
+	default:
+		return
+	}
+
+	switch n.Data {
+	case "script", "style", "head", "title", "template", "noscript", "iframe", "object":
+	case "br":
+		r.lineBreak(1)
+	case "p", "div", "section", "article", "header", "footer", "figure", "figcaption", "table", "details", "summary":
+		r.block(n)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.lineBreak(2)
+		if r.markdown {
+			r.raw(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		}
+		r.children(n)
+		r.lineBreak(2)
+	case "ul", "ol":
+		counter := 0
+		if n.Data == "ol" {
+			counter = 1
+		}
+		// Nested lists are not separated by blank lines
+		separator := 2
+		if len(r.lists) > 0 {
+			separator = 1
+		}
+		r.lineBreak(separator)
+		r.lists = append(r.lists, counter)
+		r.children(n)
+		r.lists = r.lists[:len(r.lists)-1]
+		r.lineBreak(separator)
+	case "li":
+		r.listItem(n)
+	case "blockquote":
+		r.lineBreak(2)
+		r.prefixes = append(r.prefixes, "> ")
+		r.children(n)
+		r.prefixes = r.prefixes[:len(r.prefixes)-1]
+		r.lineBreak(2)
+	case "pre":
+		r.lineBreak(2)
+		if r.markdown {
+			r.raw("```")
+			r.lineBreak(1)
+		}
+		r.pre++
+		r.children(n)
+		r.pre--
+		if r.markdown {
+			r.lineBreak(1)
+			r.raw("```")
+		}
+		r.lineBreak(2)
+	case "hr":
+		r.lineBreak(2)
+		r.raw("---")
+		r.lineBreak(2)
+	case "tr":
+		r.lineBreak(1)
+		r.children(n)
+		r.lineBreak(1)
+	case "td", "th":
+		r.children(n)
+		r.text(" ")
+	case "a":
+		r.link(n)
+	case "img":
+		r.image(n)
+	case "code", "kbd", "samp":
+		r.inline(n, "`")
+	case "strong", "b":
+		r.inline(n, "**")
+	case "em", "i":
+		r.inline(n, "*")
+	case "del", "s", "strike":
+		r.inline(n, "~~")
+	default:
+		r.children(n)
+	}
+}
+
+func (r *textRenderer) children(n *htmlparser.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		r.node(child)
+	}
+}
+
+func (r *textRenderer) block(n *htmlparser.Node) {
+	r.lineBreak(2)
+	r.children(n)
+	r.lineBreak(2)
+}
+
+func (r *textRenderer) listItem(n *htmlparser.Node) {
+	r.lineBreak(1)
+	marker := "- "
+	if depth := len(r.lists); depth > 0 && r.lists[depth-1] > 0 {
+		marker = strconv.Itoa(r.lists[depth-1]) + ". "
+		r.lists[depth-1]++
+	}
+	r.marker = marker
+	r.prefixes = append(r.prefixes, strings.Repeat(" ", len(marker)))
+	r.children(n)
+	r.prefixes = r.prefixes[:len(r.prefixes)-1]
+	r.lineBreak(1)
+}
+
+func (r *textRenderer) link(n *htmlparser.Node) {
+	href := strings.TrimSpace(nodeAttr(n, "href"))
+	if !r.markdown {
+		r.children(n)
+		text := strings.Join(strings.Fields(nodeText(n)), " ")
+		if href != "" && !strings.HasPrefix(href, "#") && href != text {
+			r.text(" (" + href + ")")
+		}
+		return
+	}
+	if href == "" {
+		r.children(n)
+		return
+	}
+	r.raw("[")
+	r.children(n)
+	r.raw("](" + escapeMarkdownURL(href) + ")")
+}
+
+func (r *textRenderer) image(n *htmlparser.Node) {
+	alt := strings.TrimSpace(nodeAttr(n, "alt"))
+	src := strings.TrimSpace(nodeAttr(n, "src"))
+	if !r.markdown || src == "" {
+		if alt != "" {
+			r.text(alt)
+		}
+		return
+	}
+	r.raw("![" + escapeMarkdown(alt) + "](" + escapeMarkdownURL(src) + ")")
+}
+
+func (r *textRenderer) inline(n *htmlparser.Node, delimiter string) {
+	if !r.markdown || r.pre > 0 || strings.TrimSpace(nodeText(n)) == "" {
+		r.children(n)
+		return
+	}
+	r.raw(delimiter)
+	if delimiter == "`" {
+		r.raw(strings.Join(strings.Fields(nodeText(n)), " "))
+	} else {
+		r.children(n)
+	}
+	r.raw(delimiter)
+}
+
+// lineBreak requests at least count line breaks before the next text.
+func (r *textRenderer) lineBreak(count int) {
+	if r.b.Len() == 0 {
+		return
+	}
+	if r.newlines == 0 {
+		r.breakPrefix = strings.Join(r.prefixes, "")
+	}
+	r.newlines = max(r.newlines, count)
+}
+
+// flush writes pending line breaks, line prefixes and the list item marker.
+func (r *textRenderer) flush() {
+	lineStart := r.b.Len() == 0 || r.newlines > 0
+	// Blank lines inside block quotes keep the quote marker,
+	// but not when entering or leaving the block quote
+	blankPrefix := strings.Join(r.prefixes, "")
+	if len(r.breakPrefix) < len(blankPrefix) {
+		blankPrefix = r.breakPrefix
+	}
+	for i := 0; i < r.newlines; i++ {
+		if i > 0 {
+			r.b.WriteString(strings.TrimRight(blankPrefix, " "))
+		}
+		r.b.WriteString("\n")
+	}
+	r.newlines = 0
+	if !lineStart {
+		return
+	}
+	prefixes := r.prefixes
+	if r.marker != "" {
+		// The marker replaces the indentation of its own list item
+		prefixes = prefixes[:len(prefixes)-1]
+	}
+	r.b.WriteString(strings.Join(prefixes, ""))
+	r.b.WriteString(r.marker)
+	r.marker = ""
+	r.lineStart = true
+}
+
+// raw writes markup without escaping.
+func (r *textRenderer) raw(s string) {
+	r.flush()
+	r.b.WriteString(s)
+	r.lineStart = false
+}
+
+// text writes text content with collapsed whitespace
+// outside of pre elements.
+func (r *textRenderer) text(s string) {
+	if r.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				r.flush()
+				r.b.WriteString("\n")
+				r.lineStart = true
+			}
+			if line != "" {
+				r.raw(line)
+			}
+		}
+		return
+	}
+
+	s = whitespacePattern.ReplaceAllString(s, " ")
+	if r.b.Len() == 0 || r.newlines > 0 || r.lineStart || strings.HasSuffix(r.b.String(), " ") {
+		s = strings.TrimLeft(s, " ")
+	}
+	if s == "" {
+		return
+	}
+	if r.markdown {
+		s = escapeMarkdown(s)
+	}
+	r.raw(s)
+}
+
+// nodeText returns the concatenated text of all descendants of n.
+func nodeText(n *htmlparser.Node) string {
+	var b strings.Builder
+	var walk func(*htmlparser.Node)
+	walk = func(n *htmlparser.Node) {
+		if n.Type == htmlparser.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+)
+
+// escapeMarkdown escapes characters that would be interpreted as Markdown.
+func escapeMarkdown(s string) string {
+	s = markdownEscaper.Replace(s)
+	if s != "" && strings.ContainsRune("#>+-", rune(s[0])) {
+		s = `\` + s
+	}
+	return s
+}
+
+// escapeMarkdownURL escapes characters that would end a Markdown link destination.
+func escapeMarkdownURL(s string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(s)
+}
@@ -0,0 +1,106 @@
+package rss
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+const textTestHTML = `<p>Hello <b>world</b>,
+	see <a href="https://example.com/post">the post</a>.</p>
+<ul><li>One</li><li>Two <em>items</em></li></ul>
+<ol><li>First</li><li>Second</li></ol>
+<blockquote><p>Quoted</p></blockquote>
+<script>alert(1)</script>`
+
+// TestHTMLToText tests conversion of HTML to plain text
+func TestHTMLToText(t *testing.T) {
+	expected := "Hello world, see the post (https://example.com/post).\n\n" +
+		"- One\n- Two items\n\n" +
+		"1. First\n2. Second\n\n" +
+		"> Quoted"
+	if got := HTMLToText(textTestHTML); got != expected {
+		t.Errorf("HTMLToText returned\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+// TestHTMLToMarkdown tests conversion of HTML to CommonMark
+func TestHTMLToMarkdown(t *testing.T) {
+	expected := "Hello **world**, see [the post](https://example.com/post).\n\n" +
+		"- One\n- Two *items*\n\n" +
+		"1. First\n2. Second\n\n" +
+		"> Quoted"
+	if got := HTMLToMarkdown(textTestHTML); got != expected {
+		t.Errorf("HTMLToMarkdown returned\n%s\nexpected\n%s", got, expected)
+	}
+
+	got := HTMLToMarkdown(`<h2>Title</h2><pre>a *b*
+c</pre><p>2*3 = 6_</p><img src="a b.png" alt="pic">`)
+	expected = "## Title\n\n```\na *b*\nc\n```\n\n2\\*3 = 6\\_\n\n![pic](a%20b.png)"
+	if got != expected {
+		t.Errorf("HTMLToMarkdown returned\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+// TestSummarize tests shortening at word boundaries without broken entities
+func TestSummarize(t *testing.T) {
+	content := "<p>Fish &amp; Chips are served every Friday</p>"
+	testCases := []struct {
+		maxLen   int
+		expected string
+	}{
+		{0, "Fish & Chips are served every Friday"},
+		{100, "Fish & Chips are served every Friday"},
+		{16, "Fish & Chips…"},
+		{7, "Fish…"},
+		{3, "Fi…"},
+	}
+	for _, tc := range testCases {
+		got := Summarize(content, tc.maxLen)
+		if got != tc.expected {
+			t.Errorf("Summarize(%d) = %q, expected %q", tc.maxLen, got, tc.expected)
+		}
+		if tc.maxLen > 0 && utf8.RuneCountInString(got) > tc.maxLen {
+			t.Errorf("Summarize(%d) returned %d characters", tc.maxLen, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+// TestSummarizeLongWord tests shortening text without spaces
+// at character boundaries
+func TestSummarizeLongWord(t *testing.T) {
+	testCases := []struct {
+		content  string
+		maxLen   int
+		expected string
+	}{
+		{"https://example.com/a/very/long/path", 10, "https://e…"},
+		{"Donaudampfschifffahrtsgesellschaft", 8, "Donauda…"},
+		// "e" with combining acute accents is not separated from its accent
+		{"Cafe\u0301e\u0301e\u0301", 6, "Cafe\u0301…"},
+		{"Cafe\u0301e\u0301e\u0301", 5, "Caf…"},
+		// The family emoji is joined with zero width joiners
+		{"ab\U0001F468\u200d\U0001F469\u200d\U0001F467", 5, "ab…"},
+	}
+	for _, tc := range testCases {
+		got := Summarize(tc.content, tc.maxLen)
+		if got != tc.expected {
+			t.Errorf("Summarize(%q, %d) = %q, expected %q", tc.content, tc.maxLen, got, tc.expected)
+		}
+		if utf8.RuneCountInString(got) > tc.maxLen {
+			t.Errorf("Summarize(%q, %d) returned %d characters", tc.content, tc.maxLen, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+// TestItemText tests the text conversion methods of items and entries
+func TestItemText(t *testing.T) {
+	item := &Item{Description: "<p>Short <i>intro</i></p>", Content: "<p>Full <i>text</i></p>"}
+	if item.Text() != "Full text" || item.Markdown() != "Full *text*" || item.Summary(0) != "Short intro" {
+		t.Errorf("Unexpected item text: %q, %q, %q", item.Text(), item.Markdown(), item.Summary(0))
+	}
+
+	entry := &Entry{Summary: Content{Type: "text", Body: "a < b"}}
+	if entry.Text() != "a < b" || entry.SummaryText(0) != "a < b" {
+		t.Errorf("Unexpected entry text: %q, %q", entry.Text(), entry.SummaryText(0))
+	}
+}
@@ -0,0 +1,599 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity is the severity of a validation Issue.
+type Severity int
+
+const (
+	// SeverityInfo marks recommendations that don't affect conformance
+	SeverityInfo Severity = iota
+
+	// SeverityWarning marks problems that are allowed by the spec
+	// but are known to cause trouble with feed readers
+	SeverityWarning
+
+	// SeverityError marks violations of the spec
+	SeverityError
+)
+
+// String implements the fmt.Stringer interface.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Issue is a conformance problem found by Validate.
+type Issue struct {
+	// Severity is the severity of the problem
+	Severity Severity
+
+	// Path is the path of the element with the problem,
+	// like "/rss/channel/item[3]/pubDate"
+	Path string
+
+	// Line and Column are the position of the element in the document
+	Line   int
+	Column int
+
+	// Message describes the problem
+	Message string
+}
+
+// String implements the fmt.Stringer interface.
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s: %s", i.Line, i.Column, i.Severity, i.Path, i.Message)
+}
+
+// MaxSeverity returns the highest severity of the issues
+// and false if there are no issues.
+func MaxSeverity(issues []Issue) (Severity, bool) {
+	if len(issues) == 0 {
+		return SeverityInfo, false
+	}
+	highest := SeverityInfo
+	for _, issue := range issues {
+		if issue.Severity > highest {
+			highest = issue.Severity
+		}
+	}
+	return highest, true
+}
+
+// rfc822Layouts are the date layouts allowed by RFC 822 as used by RSS 2.0,
+// with optional weekday and seconds and two or four digit years.
+var rfc822Layouts = func() []string {
+	var layouts []string
+	for _, weekday := range []string{"Mon, ", ""} {
+		for _, year := range []string{"2006", "06"} {
+			for _, clock := range []string{"15:04:05", "15:04"} {
+				for _, zone := range []string{"MST", "-0700"} {
+					layouts = append(layouts, weekday+"2 Jan "+year+" "+clock+" "+zone)
+				}
+			}
+		}
+	}
+	return layouts
+}()
+
+// knownPrefixes are the conventional prefixes of namespaces used in
+// element paths of issues for elements outside of the feed's namespace.
+var knownPrefixes = map[string]string{
+	atomNamespace:                                "atom",
+	rdfNamespace:                                 "rdf",
+	"http://purl.org/dc/elements/1.1/":           "dc",
+	"http://purl.org/rss/1.0/modules/content/":   "content",
+	"http://search.yahoo.com/mrss/":              "media",
+	"http://www.itunes.com/dtds/podcast-1.0.dtd": "itunes",
+}
+
+// xmlNode is an element of the document tree built by Validate.
+type xmlNode struct {
+	name     xml.Name
+	attr     []xml.Attr
+	text     string
+	children []*xmlNode
+	line     int
+	column   int
+	path     string
+}
+
+// attrValue returns the value of the attribute without namespace with the given name.
+func (n *xmlNode) attrValue(name string) (string, bool) {
+	for _, attr := range n.attr {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Validate checks an RSS 2.0 or Atom 1.0 document for conformance with its spec
+// and returns the found issues ordered by their position in the document.
+// The context is used for cancellation control during validation.
+//
+// Checked are missing required elements and attributes, unparseable dates,
+// duplicate GUIDs and IDs, relative URLs where absolute URLs are required,
+// invalid enclosure lengths and MIME types and Atom entries without id or updated.
+//
+// A document that is not well-formed XML results in a single issue with
+// SeverityError at the position of the syntax error. An error is only returned
+// if the document can not be read, or if its root element is neither rss nor feed,
+// in which case the error wraps ErrUnknownFormat.
+// The reader is not closed by this function; the caller is responsible for closing it.
+func Validate(ctx context.Context, r io.Reader) ([]Issue, error) {
+	// Check if context is cancelled before starting
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	root, err := parseTree(newDecoder(r, ""))
+	if err != nil {
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return []Issue{{Severity: SeverityError, Path: "/", Line: syntaxErr.Line, Message: syntaxErr.Msg}}, nil
+		}
+		return nil, err
+	}
+
+	var v validator
+	switch {
+	case root.name.Local == "rss":
+		v.validateRSS(root)
+	case root.name.Local == "feed" && (root.name.Space == atomNamespace || root.name.Space == ""):
+		v.space = root.name.Space
+		v.validateAtom(root)
+	default:
+		return nil, fmt.Errorf("%w: root element <%s>", ErrUnknownFormat, root.name.Local)
+	}
+
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		return v.issues[i].Line < v.issues[j].Line
+	})
+	return v.issues, nil
+}
+
+// parseTree reads the document into a tree of elements
+// with their positions and paths.
+func parseTree(xmlDecoder *xml.Decoder) (*xmlNode, error) {
+	var (
+		root  *xmlNode
+		stack []*xmlNode
+	)
+	for {
+		token, err := xmlDecoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, column := xmlDecoder.InputPos()
+			node := &xmlNode{name: t.Name, attr: t.Copy().Attr, line: line, column: column}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	root.path = "/" + pathName(root.name, root.name.Space)
+	setPaths(root, root.name.Space)
+	return root, nil
+}
+
+// setPaths sets the paths of the children of parent, adding the
+// position among siblings of the same name if there are several.
+func setPaths(parent *xmlNode, space string) {
+	counts := make(map[xml.Name]int)
+	for _, child := range parent.children {
+		counts[child.name]++
+	}
+	indexes := make(map[xml.Name]int)
+	for _, child := range parent.children {
+		child.path = parent.path + "/" + pathName(child.name, space)
+		if counts[child.name] > 1 {
+			indexes[child.name]++
+			child.path += "[" + strconv.Itoa(indexes[child.name]) + "]"
+		}
+		setPaths(child, space)
+	}
+}
+
+// pathName returns the name of an element for paths, using the known
+// prefix for elements outside the default namespace of the document.
+func pathName(name xml.Name, space string) string {
+	if name.Space == "" || name.Space == space {
+		return name.Local
+	}
+	if prefix, ok := knownPrefixes[name.Space]; ok {
+		return prefix + ":" + name.Local
+	}
+	return name.Local
+}
+
+// validator collects the issues of a document.
+type validator struct {
+	// space is the namespace of the elements of the feed format
+	space  string
+	issues []Issue
+}
+
+func (v *validator) add(severity Severity, node *xmlNode, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		Severity: severity,
+		Path:     node.path,
+		Line:     node.line,
+		Column:   node.column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// children returns the child elements with the given name in the namespace of the feed.
+func (v *validator) children(node *xmlNode, name string) []*xmlNode {
+	var children []*xmlNode
+	for _, child := range node.children {
+		if child.name.Local == name && child.name.Space == v.space {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// child returns the first child element with the given name or nil.
+func (v *validator) child(node *xmlNode, name string) *xmlNode {
+	if children := v.children(node, name); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// required returns the child element with the given name and reports
+// an error if it is missing or a warning if it is empty.
+func (v *validator) required(node *xmlNode, name string) *xmlNode {
+	child := v.child(node, name)
+	switch {
+	case child == nil:
+		v.add(SeverityError, node, "missing required element <%s>", name)
+	case strings.TrimSpace(child.text) == "" && len(child.children) == 0:
+		v.add(SeverityWarning, child, "element <%s> is empty", name)
+	}
+	return child
+}
+
+// single reports an error if there is more than one child element with the given name.
+func (v *validator) single(node *xmlNode, names ...string) {
+	for _, name := range names {
+		if children := v.children(node, name); len(children) > 1 {
+			v.add(SeverityError, children[1], "element <%s> must not occur more than once", name)
+		}
+	}
+}
+
+// absoluteURL reports an error if the text of node is not an absolute URL.
+func (v *validator) absoluteURL(node *xmlNode, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	switch {
+	case err != nil:
+		v.add(SeverityError, node, "invalid URL %q", value)
+	case !u.IsAbs():
+		v.add(SeverityError, node, "relative URL %q, an absolute URL is required", value)
+	}
+}
+
+// mediaType reports an error if value is not a valid MIME type like "audio/mpeg".
+func (v *validator) mediaType(node *xmlNode, attr, value string) {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		v.add(SeverityError, node, "attribute %s %q is not a valid MIME type", attr, value)
+	}
+}
+
+// length reports an error if value is not a non-negative number of bytes.
+func (v *validator) length(node *xmlNode, value string) {
+	if _, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64); err != nil {
+		v.add(SeverityError, node, "attribute length %q is not a non-negative integer", value)
+	}
+}
+
+func (v *validator) validateRSS(root *xmlNode) {
+	switch version, ok := root.attrValue("version"); {
+	case !ok:
+		v.add(SeverityError, root, "missing required attribute version")
+	case version != "2.0":
+		v.add(SeverityInfo, root, "version %q is validated as RSS 2.0", version)
+	}
+
+	channels := v.children(root, "channel")
+	if len(channels) == 0 {
+		v.add(SeverityError, root, "missing required element <channel>")
+		return
+	}
+	v.single(root, "channel")
+	channel := channels[0]
+
+	v.required(channel, "title")
+	v.required(channel, "description")
+	if link := v.required(channel, "link"); link != nil {
+		v.absoluteURL(link, link.text)
+	}
+	v.single(channel, "title", "link", "description", "language", "pubDate", "lastBuildDate", "ttl", "image")
+	for _, name := range []string{"pubDate", "lastBuildDate"} {
+		if date := v.child(channel, name); date != nil {
+			v.rfc822Date(date)
+		}
+	}
+	if ttl := v.child(channel, "ttl"); ttl != nil {
+		if _, err := strconv.ParseUint(strings.TrimSpace(ttl.text), 10, 32); err != nil {
+			v.add(SeverityError, ttl, "ttl %q is not a number of minutes", ttl.text)
+		}
+	}
+	if image := v.child(channel, "image"); image != nil {
+		v.required(image, "title")
+		for _, name := range []string{"url", "link"} {
+			if child := v.required(image, name); child != nil {
+				v.absoluteURL(child, child.text)
+			}
+		}
+	}
+
+	guids := make(map[string]*xmlNode)
+	for _, item := range v.children(channel, "item") {
+		v.validateItem(item, guids)
+	}
+}
+
+func (v *validator) validateItem(item *xmlNode, guids map[string]*xmlNode) {
+	if v.child(item, "title") == nil && v.child(item, "description") == nil {
+		v.add(SeverityError, item, "item must contain at least one of <title> or <description>")
+	}
+	v.single(item, "title", "link", "description", "author", "comments", "guid", "pubDate", "source")
+
+	for _, name := range []string{"link", "comments"} {
+		if child := v.child(item, name); child != nil {
+			v.absoluteURL(child, child.text)
+		}
+	}
+	if pubDate := v.child(item, "pubDate"); pubDate != nil {
+		v.rfc822Date(pubDate)
+	}
+
+	if guid := v.child(item, "guid"); guid != nil {
+		value := strings.TrimSpace(guid.text)
+		if first, ok := guids[value]; ok {
+			v.add(SeverityError, guid, "duplicate guid %q, first used at line %d", value, first.line)
+		} else {
+			guids[value] = guid
+		}
+		if isPermaLink, _ := guid.attrValue("isPermaLink"); isPermaLink != "false" {
+			if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+				v.add(SeverityWarning, guid, "guid %q is not a URL, add isPermaLink=\"false\"", value)
+			}
+		}
+	} else {
+		v.add(SeverityInfo, item, "item has no <guid>, feed readers may show it again when it changes")
+	}
+
+	for _, enclosure := range v.children(item, "enclosure") {
+		for _, attr := range []string{"url", "length", "type"} {
+			if _, ok := enclosure.attrValue(attr); !ok {
+				v.add(SeverityError, enclosure, "missing required attribute %s", attr)
+			}
+		}
+		if value, ok := enclosure.attrValue("url"); ok {
+			v.absoluteURL(enclosure, value)
+		}
+		if value, ok := enclosure.attrValue("length"); ok {
+			v.length(enclosure, value)
+		}
+		if value, ok := enclosure.attrValue("type"); ok {
+			v.mediaType(enclosure, "type", value)
+		}
+	}
+}
+
+// rfc822Date reports an error if the text of node is not a date,
+// or a warning if it is a date in a format other than RFC 822.
+func (v *validator) rfc822Date(node *xmlNode) {
+	value := strings.TrimSpace(node.text)
+	for _, layout := range rfc822Layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return
+		}
+	}
+	if _, err := Date(value).Parse(); err == nil {
+		v.add(SeverityWarning, node, "date %q is not in RFC 822 format", value)
+		return
+	}
+	v.add(SeverityError, node, "unparseable date %q", value)
+}
+
+func (v *validator) validateAtom(root *xmlNode) {
+	if root.name.Space == "" {
+		v.add(SeverityWarning, root, "missing Atom namespace %s", atomNamespace)
+	}
+
+	v.atomID(v.required(root, "id"))
+	v.required(root, "title")
+	v.rfc3339Date(v.required(root, "updated"))
+	v.single(root, "id", "title", "updated", "subtitle", "rights", "icon", "logo", "generator")
+	v.atomLinks(root)
+	v.atomPersons(root)
+
+	hasSelf := false
+	for _, link := range v.children(root, "link") {
+		if rel, _ := link.attrValue("rel"); rel == "self" {
+			hasSelf = true
+		}
+	}
+	if !hasSelf {
+		v.add(SeverityInfo, root, "feed should have a link with rel=\"self\"")
+	}
+
+	entries := v.children(root, "entry")
+	feedHasAuthor := v.child(root, "author") != nil
+	type idUpdated struct{ id, updated string }
+	ids := make(map[string]*xmlNode)
+	versions := make(map[idUpdated]bool)
+	for _, entry := range entries {
+		id := v.required(entry, "id")
+		v.atomID(id)
+		v.required(entry, "title")
+		updated := v.required(entry, "updated")
+		v.rfc3339Date(updated)
+		if published := v.child(entry, "published"); published != nil {
+			v.rfc3339Date(published)
+		}
+		v.single(entry, "id", "title", "updated", "published", "summary", "content", "rights", "source")
+		v.atomLinks(entry)
+		v.atomPersons(entry)
+
+		if !feedHasAuthor && v.child(entry, "author") == nil && v.child(entry, "source") == nil {
+			v.add(SeverityError, entry, "entry has no <author> and the feed has none")
+		}
+
+		content := v.child(entry, "content")
+		if content == nil && !v.hasAlternateLink(entry) {
+			v.add(SeverityError, entry, "entry without <content> must have a link with rel=\"alternate\"")
+		}
+		for _, text := range []*xmlNode{content, v.child(entry, "summary"), v.child(entry, "title")} {
+			if text != nil {
+				v.atomTextType(text)
+			}
+		}
+
+		if id == nil || updated == nil {
+			continue
+		}
+		key := idUpdated{strings.TrimSpace(id.text), strings.TrimSpace(updated.text)}
+		switch first, ok := ids[key.id]; {
+		case versions[key]:
+			v.add(SeverityError, id, "duplicate id %q with the same updated date, first used at line %d", key.id, first.line)
+		case ok:
+			v.add(SeverityWarning, id, "duplicate id %q, first used at line %d", key.id, first.line)
+		default:
+			ids[key.id] = id
+		}
+		versions[key] = true
+	}
+}
+
+// atomID reports an error if the id is not an absolute IRI.
+func (v *validator) atomID(id *xmlNode) {
+	if id == nil {
+		return
+	}
+	value := strings.TrimSpace(id.text)
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+		v.add(SeverityError, id, "id %q is not an absolute IRI", value)
+	}
+}
+
+func (v *validator) atomLinks(node *xmlNode) {
+	for _, link := range v.children(node, "link") {
+		if href, ok := link.attrValue("href"); !ok || strings.TrimSpace(href) == "" {
+			v.add(SeverityError, link, "missing required attribute href")
+		}
+		if value, ok := link.attrValue("type"); ok {
+			v.mediaType(link, "type", value)
+		}
+		if value, ok := link.attrValue("length"); ok {
+			v.length(link, value)
+		}
+	}
+}
+
+func (v *validator) atomPersons(node *xmlNode) {
+	for _, name := range []string{"author", "contributor"} {
+		for _, person := range v.children(node, name) {
+			v.required(person, "name")
+			if uri := v.child(person, "uri"); uri != nil {
+				if _, err := url.Parse(strings.TrimSpace(uri.text)); err != nil {
+					v.add(SeverityError, uri, "invalid URI %q", uri.text)
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) hasAlternateLink(entry *xmlNode) bool {
+	for _, link := range v.children(entry, "link") {
+		if rel, ok := link.attrValue("rel"); !ok || rel == "alternate" {
+			return true
+		}
+	}
+	return false
+}
+
+// atomTextType reports an error if the type attribute of
+// a text or content construct is not valid.
+func (v *validator) atomTextType(node *xmlNode) {
+	value, ok := node.attrValue("type")
+	if !ok {
+		return
+	}
+	switch value {
+	case "text", "html", "xhtml":
+		return
+	}
+	if node.name.Local != "content" {
+		v.add(SeverityError, node, "type %q must be text, html or xhtml", value)
+		return
+	}
+	v.mediaType(node, "type", value)
+}
+
+// rfc3339Date reports an error if the text of node is not an RFC 3339 date.
+func (v *validator) rfc3339Date(node *xmlNode) {
+	if node == nil {
+		return
+	}
+	value := strings.TrimSpace(node.text)
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		v.add(SeverityError, node, "unparseable date %q, RFC 3339 format is required", value)
+	}
+}
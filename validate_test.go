@@ -0,0 +1,180 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// findIssue returns the first issue for the path containing the message part.
+func findIssue(issues []Issue, path, messagePart string) *Issue {
+	for i := range issues {
+		if issues[i].Path == path && strings.Contains(issues[i].Message, messagePart) {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+// TestValidateRSS tests the RSS 2.0 conformance checks
+func TestValidateRSS(t *testing.T) {
+	rssData := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+	<title>Test Channel</title>
+	<link>/relative</link>
+	<item>
+		<title>First</title>
+		<guid isPermaLink="false">1</guid>
+		<pubDate>Mon, 01 Jan 2024 12:00:00 +0000</pubDate>
+		<enclosure url="http://example.com/a.mp3" length="12.5" type="audio"/>
+	</item>
+	<item>
+		<title>Second</title>
+		<guid isPermaLink="false">1</guid>
+		<pubDate>yesterday</pubDate>
+		<enclosure url="http://example.com/b.mp3"/>
+	</item>
+	<item>
+		<link>http://example.com/3</link>
+		<pubDate>2024-01-03T12:00:00Z</pubDate>
+	</item>
+</channel>
+</rss>`
+
+	issues, err := Validate(context.Background(), strings.NewReader(rssData))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	expected := []struct {
+		severity    Severity
+		path        string
+		messagePart string
+		line        int
+	}{
+		{SeverityError, "/rss/channel", "missing required element <description>", 3},
+		{SeverityError, "/rss/channel/link", "relative URL", 5},
+		{SeverityError, "/rss/channel/item[1]/enclosure", "length", 10},
+		{SeverityError, "/rss/channel/item[1]/enclosure", "MIME type", 10},
+		{SeverityError, "/rss/channel/item[2]/guid", "duplicate guid", 14},
+		{SeverityError, "/rss/channel/item[2]/pubDate", "unparseable date", 15},
+		{SeverityError, "/rss/channel/item[2]/enclosure", "missing required attribute length", 16},
+		{SeverityError, "/rss/channel/item[3]", "at least one of <title> or <description>", 18},
+		{SeverityWarning, "/rss/channel/item[3]/pubDate", "not in RFC 822 format", 20},
+	}
+	for _, e := range expected {
+		issue := findIssue(issues, e.path, e.messagePart)
+		if issue == nil {
+			t.Errorf("Missing issue %q at %s", e.messagePart, e.path)
+			continue
+		}
+		if issue.Severity != e.severity || issue.Line != e.line {
+			t.Errorf("Expected %s at line %d, got %s", e.severity, e.line, issue)
+		}
+	}
+	if severity, ok := MaxSeverity(issues); !ok || severity != SeverityError {
+		t.Errorf("Expected max severity error, got %s", severity)
+	}
+	for i := 1; i < len(issues); i++ {
+		if issues[i].Line < issues[i-1].Line {
+			t.Errorf("Issues are not ordered by line: %s before %s", issues[i-1], issues[i])
+		}
+	}
+}
+
+// TestValidateAtom tests the Atom 1.0 conformance checks
+func TestValidateAtom(t *testing.T) {
+	atomData := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+	<title>Test Feed</title>
+	<updated>2024-01-01T12:00:00Z</updated>
+	<link rel="self" href="https://example.com/feed.atom"/>
+	<author><name>Jane</name></author>
+	<entry>
+		<title>No ID</title>
+		<link href="https://example.com/1"/>
+	</entry>
+	<entry>
+		<id>relative-id</id>
+		<title>Bad Date</title>
+		<updated>January 2nd</updated>
+		<content type="html">Hello</content>
+	</entry>
+	<entry>
+		<id>urn:1</id>
+		<title>Duplicate</title>
+		<updated>2024-01-01T12:00:00Z</updated>
+		<content>Text</content>
+	</entry>
+	<entry>
+		<id>urn:1</id>
+		<title>Duplicate</title>
+		<updated>2024-01-01T12:00:00Z</updated>
+	</entry>
+</feed>`
+
+	issues, err := Validate(context.Background(), strings.NewReader(atomData))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	expected := []struct {
+		path        string
+		messagePart string
+	}{
+		{"/feed/entry[1]", "missing required element <id>"},
+		{"/feed/entry[1]", "missing required element <updated>"},
+		{"/feed/entry[2]/id", "not an absolute IRI"},
+		{"/feed/entry[2]/updated", "RFC 3339"},
+		{"/feed/entry[4]/id", "duplicate id \"urn:1\" with the same updated date"},
+		{"/feed/entry[4]", "must have a link with rel=\"alternate\""},
+	}
+	for _, e := range expected {
+		if issue := findIssue(issues, e.path, e.messagePart); issue == nil || issue.Severity != SeverityError {
+			t.Errorf("Missing error %q at %s in %v", e.messagePart, e.path, issues)
+		}
+	}
+	if issue := findIssue(issues, "/feed/entry[3]", ""); issue != nil {
+		t.Errorf("Unexpected issue for valid entry: %s", issue)
+	}
+}
+
+// TestValidateTestFiles tests that the valid test feeds have no errors
+// and that the non-absolute ids of the Reddit feed are reported
+func TestValidateTestFiles(t *testing.T) {
+	testCases := map[string]bool{
+		"podcast.rss":       false,
+		"wordpress.rss":     false,
+		"reddit-google.rss": true,
+	}
+	for filename, expectErrors := range testCases {
+		t.Run(filename, func(t *testing.T) {
+			file, err := os.Open(filepath.Join(testDataDir, filename))
+			if err != nil {
+				t.Fatalf("Failed to open test file: %v", err)
+			}
+			defer file.Close()
+
+			issues, err := Validate(context.Background(), file)
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			severity, _ := MaxSeverity(issues)
+			if hasErrors := severity == SeverityError; hasErrors != expectErrors {
+				t.Errorf("Expected errors: %t, got issues %v", expectErrors, issues)
+			}
+		})
+	}
+
+	issues, err := Validate(context.Background(), strings.NewReader(`<rss version="2.0"><channel>`))
+	if err != nil || len(issues) != 1 || issues[0].Severity != SeverityError {
+		t.Errorf("Expected one syntax error issue, got %v, %v", issues, err)
+	}
+	if _, err := Validate(context.Background(), strings.NewReader(`<html></html>`)); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,564 @@
+package rss
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDistributionSize is the maximum size of content distributed by a WebSub hub.
+const maxDistributionSize = 10 << 20
+
+var (
+	// ErrNoHub is returned by Subscriber.SubscribeFeed for feeds without WebSub hub.
+	ErrNoHub = errors.New("feed has no WebSub hub")
+
+	// ErrSubscriptionDenied is passed to Subscriber.OnError
+	// if a hub denies or cancels a subscription.
+	ErrSubscriptionDenied = errors.New("subscription denied by hub")
+
+	// ErrInvalidSignature is passed to Subscriber.OnError for distributed
+	// content with a missing or wrong X-Hub-Signature header.
+	ErrInvalidSignature = errors.New("invalid X-Hub-Signature")
+)
+
+// signatureHashes are the hash functions of the X-Hub-Signature methods.
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// HubLinks are the WebSub links of a feed.
+type HubLinks struct {
+	// Hubs are the URLs of the hubs distributing the feed
+	Hubs []string
+
+	// Self is the canonical URL of the feed, the topic to subscribe to
+	Self string
+}
+
+// HubLinks returns the WebSub links of the channel's atom:link elements.
+func (c *Channel) HubLinks() HubLinks {
+	return hubLinks(c.AtomLinks)
+}
+
+// HubLinks returns the WebSub links of the feed.
+func (f *Feed) HubLinks() HubLinks {
+	return hubLinks(f.Link)
+}
+
+func hubLinks(links []Link) HubLinks {
+	var hub HubLinks
+	for _, link := range links {
+		href := strings.TrimSpace(link.Href)
+		switch {
+		case href == "":
+		case hasLinkRel(link.Rel, "hub"):
+			hub.Hubs = append(hub.Hubs, href)
+		case hasLinkRel(link.Rel, "self") && hub.Self == "":
+			hub.Self = href
+		}
+	}
+	return hub
+}
+
+// DiscoverHub fetches the feed and returns its WebSub links.
+// Links in the HTTP Link header take precedence over links in the feed
+// as required by the WebSub spec. If neither has a self link,
+// the URL of the feed after redirects is used as topic.
+//
+// The returned Hubs are empty if the feed has no hub.
+// A nil client means http.DefaultClient.
+func DiscoverHub(ctx context.Context, feedURL string, client *http.Client) (*HubLinks, error) {
+	req, err := newRequest(ctx, feedURL, false)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := do(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	finalURL := req.URL
+	if resp.Request != nil {
+		finalURL = resp.Request.URL
+	}
+
+	hub := hubLinks(parseLinkHeader(resp.Header.Values("Link"), finalURL))
+	if len(hub.Hubs) == 0 || hub.Self == "" {
+		contentType := resp.Header.Get("Content-Type")
+		var feedHub HubLinks
+		if format, err := detectFormat(data, contentType); err == nil {
+			parsed, err := parseFormat(ctx, data, format, contentType, finalURL, ParseOptions{})
+			if err != nil {
+				return nil, err
+			}
+			switch format {
+			case FormatRSS:
+				feedHub = parsed.Channel.HubLinks()
+			case FormatAtom:
+				feedHub = parsed.Feed.HubLinks()
+			}
+		}
+		if len(hub.Hubs) == 0 {
+			hub.Hubs = feedHub.Hubs
+		}
+		if hub.Self == "" {
+			hub.Self = feedHub.Self
+		}
+	}
+	if hub.Self == "" {
+		hub.Self = finalURL.String()
+	}
+	return &hub, nil
+}
+
+// parseLinkHeader parses the links of HTTP Link headers (RFC 8288)
+// and resolves them against base.
+func parseLinkHeader(values []string, base *url.URL) []Link {
+	var links []Link
+	for _, value := range values {
+		for {
+			start := strings.IndexByte(value, '<')
+			end := strings.IndexByte(value, '>')
+			if start < 0 || end < start {
+				break
+			}
+			href := value[start+1 : end]
+			value = value[end+1:]
+
+			// The parameters end at the next link
+			params := value
+			if next := strings.IndexByte(value, '<'); next >= 0 {
+				params = value[:next]
+			}
+			link := Link{Href: href}
+			for _, param := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				val = strings.Trim(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(val), ",")), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "rel":
+					link.Rel = val
+				case "type":
+					link.Type = val
+				case "title":
+					link.Title = val
+				}
+			}
+			if ref, err := url.Parse(strings.TrimSpace(link.Href)); err == nil {
+				link.Href = base.ResolveReference(ref).String()
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// Subscription is a WebSub subscription of a Subscriber.
+type Subscription struct {
+	// ID identifies the subscription in its callback URL
+	ID string
+
+	// Hub is the URL of the hub
+	Hub string
+
+	// Topic is the URL of the subscribed feed
+	Topic string
+
+	// Secret is used by the hub to sign distributed content.
+	// It is only sent to hubs with https URLs as recommended by WebSub,
+	// requests with a secret to other hubs fail with an error.
+	Secret string
+
+	// Verified is true after the hub verified the subscription
+	Verified bool
+
+	// Expires is the end of the lease granted by the hub,
+	// zero until the subscription is verified
+	Expires time.Time
+
+	// unsubscribing is set while an unsubscription is pending verification
+	unsubscribing bool
+}
+
+// Subscriber subscribes to feeds at WebSub hubs and receives their content.
+// It is an http.Handler that must be reachable by the hubs at CallbackURL,
+// for example mounted with http.StripPrefix or at the root of a server.
+//
+// Hubs send content as feed documents which are parsed like ParseResponse
+// and passed to OnFeed.
+type Subscriber struct {
+	// CallbackURL is the public URL of the handler. The ID of a
+	// subscription is appended as path segment to form its callback.
+	CallbackURL string
+
+	// Client is used for requests to hubs, nil means http.DefaultClient
+	Client *http.Client
+
+	// LeaseSeconds is the requested lease of subscriptions,
+	// zero lets the hub decide
+	LeaseSeconds int
+
+	// OnFeed is called with the content distributed for a subscription.
+	// It is called by the handler before responding to the hub and should
+	// not block, as hubs time out slow subscribers.
+	OnFeed func(ctx context.Context, sub Subscription, feed *ParsedFeed)
+
+	// OnError is called for content that could not be parsed or has an
+	// invalid signature and for denied subscriptions, may be nil
+	OnError func(sub Subscription, err error)
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+}
+
+// NewSubscriber returns a Subscriber with the callback URL
+// that passes distributed content to onFeed.
+func NewSubscriber(callbackURL string, onFeed func(ctx context.Context, sub Subscription, feed *ParsedFeed)) *Subscriber {
+	return &Subscriber{
+		CallbackURL:   callbackURL,
+		OnFeed:        onFeed,
+		subscriptions: make(map[string]*Subscription),
+	}
+}
+
+// Subscribe requests a subscription to the topic at the hub.
+// The subscription becomes active when the hub verifies it
+// with a request to the handler.
+//
+// A secret for signing the content is only created for hubs with https URLs,
+// because sending it in clear text defeats the signature. Content from hubs
+// with http URLs is accepted unsigned.
+func (s *Subscriber) Subscribe(ctx context.Context, hub, topic string) (Subscription, error) {
+	sub := &Subscription{
+		ID:    randomToken(16),
+		Hub:   hub,
+		Topic: topic,
+	}
+	if secureHub(hub) {
+		sub.Secret = randomToken(32)
+	}
+	s.mu.Lock()
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[string]*Subscription)
+	}
+	s.subscriptions[sub.ID] = sub
+	// The hub may verify the subscription before the request returns
+	pending := *sub
+	s.mu.Unlock()
+
+	if err := s.request(ctx, "subscribe", pending); err != nil {
+		s.remove(pending.ID)
+		return Subscription{}, err
+	}
+	return pending, nil
+}
+
+// SubscribeFeed discovers the hub and topic of the feed with DiscoverHub
+// and subscribes to the topic at the first hub.
+// Returns ErrNoHub if the feed has no hub.
+func (s *Subscriber) SubscribeFeed(ctx context.Context, feedURL string) (Subscription, error) {
+	hub, err := DiscoverHub(ctx, feedURL, s.client())
+	if err != nil {
+		return Subscription{}, err
+	}
+	if len(hub.Hubs) == 0 {
+		return Subscription{}, fmt.Errorf("%w: %s", ErrNoHub, feedURL)
+	}
+	return s.Subscribe(ctx, hub.Hubs[0], hub.Self)
+}
+
+// Renew requests the renewal of the lease of a subscription.
+func (s *Subscriber) Renew(ctx context.Context, id string) error {
+	sub, ok := s.Subscription(id)
+	if !ok {
+		return fmt.Errorf("unknown subscription %q", id)
+	}
+	return s.request(ctx, "subscribe", sub)
+}
+
+// RenewExpiring renews the subscriptions whose lease ends within the duration.
+// It is meant to be called periodically and returns the joined errors of all renewals.
+func (s *Subscriber) RenewExpiring(ctx context.Context, within time.Duration) error {
+	deadline := time.Now().Add(within)
+	var errs []error
+	for _, sub := range s.Subscriptions() {
+		if !sub.Expires.IsZero() && sub.Expires.Before(deadline) {
+			if err := s.request(ctx, "subscribe", sub); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Unsubscribe requests the end of a subscription.
+// It is removed when the hub verifies the unsubscription.
+// If the request fails, the subscription stays active.
+func (s *Subscriber) Unsubscribe(ctx context.Context, id string) error {
+	s.mu.Lock()
+	sub, ok := s.subscriptions[id]
+	var pending Subscription
+	if ok {
+		sub.unsubscribing = true
+		pending = *sub
+	}
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown subscription %q", id)
+	}
+	if err := s.request(ctx, "unsubscribe", pending); err != nil {
+		s.mu.Lock()
+		if sub, ok := s.subscriptions[id]; ok {
+			sub.unsubscribing = false
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Restore adds a subscription returned by Subscribe or Subscriptions,
+// for example one saved before a restart, so that the handler accepts
+// the verifications and content of its hub again. It replaces the
+// subscription with the same ID. Subscriptions that were not verified
+// or whose lease expired have to be renewed with Renew.
+func (s *Subscriber) Restore(sub Subscription) error {
+	if sub.ID == "" || sub.Hub == "" || sub.Topic == "" {
+		return fmt.Errorf("failed to restore subscription %q: ID, hub and topic are required", sub.ID)
+	}
+	sub.unsubscribing = false
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[string]*Subscription)
+	}
+	s.subscriptions[sub.ID] = &sub
+	return nil
+}
+
+// Subscription returns the subscription with the ID.
+func (s *Subscriber) Subscription(id string) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return Subscription{}, false
+	}
+	return *sub, true
+}
+
+// Subscriptions returns all subscriptions, including the ones
+// not yet verified by their hub.
+func (s *Subscriber) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := make([]Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subs = append(subs, *sub)
+	}
+	return subs
+}
+
+// ServeHTTP implements the http.Handler interface.
+// It answers verification requests of hubs with GET
+// and receives distributed content with POST.
+func (s *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	switch r.Method {
+	case http.MethodGet:
+		s.verify(w, r, id)
+	case http.MethodPost:
+		s.receive(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify answers the verification of intent of a hub.
+func (s *Subscriber) verify(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+
+	s.mu.Lock()
+	sub, ok := s.subscriptions[id]
+	if !ok || query.Get("hub.topic") != sub.Topic {
+		s.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case mode == "denied":
+		delete(s.subscriptions, id)
+		s.mu.Unlock()
+		s.reportError(*sub, fmt.Errorf("%w: %s", ErrSubscriptionDenied, query.Get("hub.reason")))
+		w.WriteHeader(http.StatusOK)
+		return
+	case mode == "subscribe" && !sub.unsubscribing:
+		sub.Verified = true
+		if lease, err := strconv.Atoi(query.Get("hub.lease_seconds")); err == nil && lease > 0 {
+			sub.Expires = time.Now().Add(time.Duration(lease) * time.Second)
+		}
+	case mode == "unsubscribe" && sub.unsubscribing:
+		delete(s.subscriptions, id)
+	default:
+		s.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, query.Get("hub.challenge"))
+}
+
+// receive handles content distributed by a hub.
+func (s *Subscriber) receive(w http.ResponseWriter, r *http.Request, id string) {
+	sub, ok := s.Subscription(id)
+	if !ok || sub.unsubscribing {
+		// Tells the hub to remove the subscription
+		http.Error(w, "unknown subscription", http.StatusGone)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDistributionSize))
+	if err != nil {
+		http.Error(w, "failed to read content", http.StatusRequestEntityTooLarge)
+		return
+	}
+	// The content is acknowledged even if it is ignored,
+	// so that the hub doesn't retry invalid content
+	w.WriteHeader(http.StatusOK)
+
+	if sub.Secret != "" && !validSignature(r.Header.Get("X-Hub-Signature"), sub.Secret, data) {
+		s.reportError(sub, ErrInvalidSignature)
+		return
+	}
+	feed, err := parsePushed(r.Context(), data, r.Header.Get("Content-Type"), sub.Topic)
+	if err != nil {
+		s.reportError(sub, err)
+		return
+	}
+	if s.OnFeed != nil {
+		s.OnFeed(r.Context(), sub, feed)
+	}
+}
+
+// parsePushed parses distributed content of the topic.
+func parsePushed(ctx context.Context, data []byte, contentType, topic string) (*ParsedFeed, error) {
+	format, err := detectFormat(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w (Content-Type %q)", err, contentType)
+	}
+	topicURL, _ := url.Parse(topic)
+	return parseFormat(ctx, data, format, contentType, topicURL, ParseOptions{})
+}
+
+// request sends a subscription request with the mode to the hub of the subscription.
+func (s *Subscriber) request(ctx context.Context, mode string, sub Subscription) error {
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {sub.Topic},
+		"hub.callback": {strings.TrimSuffix(s.CallbackURL, "/") + "/" + sub.ID},
+	}
+	if mode == "subscribe" {
+		if sub.Secret != "" {
+			if !secureHub(sub.Hub) {
+				return fmt.Errorf("failed to %s to %s: refusing to send the secret to the insecure hub %s", mode, sub.Topic, sub.Hub)
+			}
+			form.Set("hub.secret", sub.Secret)
+		}
+		if s.LeaseSeconds > 0 {
+			form.Set("hub.lease_seconds", strconv.Itoa(s.LeaseSeconds))
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create hub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("user-agent", "go-rss/1.0.0")
+	resp, err := do(s.client(), req, sub.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to %s to %s at %s: %w", mode, sub.Topic, sub.Hub, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *Subscriber) remove(id string) {
+	s.mu.Lock()
+	delete(s.subscriptions, id)
+	s.mu.Unlock()
+}
+
+func (s *Subscriber) reportError(sub Subscription, err error) {
+	if s.OnError != nil {
+		s.OnError(sub, err)
+	}
+}
+
+// secureHub reports if the hub URL uses https, so that a secret can be sent to it.
+func secureHub(hub string) bool {
+	u, err := url.Parse(strings.TrimSpace(hub))
+	return err == nil && u.Scheme == "https"
+}
+
+func (s *Subscriber) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+// validSignature checks the X-Hub-Signature header value, which is
+// the hash method and the hex encoded HMAC of the body, like "sha256=...".
+func validSignature(signature, secret string, body []byte) bool {
+	method, digest, ok := strings.Cut(signature, "=")
+	newHash, known := signatureHashes[strings.ToLower(method)]
+	if !ok || !known {
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// randomToken returns n random bytes hex encoded.
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,480 @@
+package rss
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestChannelHubLinks tests the WebSub links of RSS channels
+func TestChannelHubLinks(t *testing.T) {
+	file, err := os.Open(filepath.Join(testDataDir, "wordpress.rss"))
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+	channel, err := ParseRegular(context.Background(), file)
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+
+	// atom:link elements must not overwrite the channel link
+	if channel.Link != "http://rottenindenmark.wordpress.com" {
+		t.Errorf("Unexpected channel link %q", channel.Link)
+	}
+	hub := channel.HubLinks()
+	if len(hub.Hubs) != 1 || hub.Hubs[0] != "http://rottenindenmark.wordpress.com/?pushpress=hub" {
+		t.Errorf("Unexpected hubs %v", hub.Hubs)
+	}
+	if hub.Self != "http://rottenindenmark.wordpress.com/feed/" {
+		t.Errorf("Unexpected self link %q", hub.Self)
+	}
+}
+
+// TestParseLinkHeader tests parsing HTTP Link headers
+func TestParseLinkHeader(t *testing.T) {
+	base, _ := url.Parse("https://example.com/feed")
+	links := parseLinkHeader([]string{
+		`<https://hub.example.com/>; rel="hub", </feed.xml>; rel=self; type="application/rss+xml"`,
+		`<https://other.example.com/hub>; rel="hub"`,
+	}, base)
+	expected := []Link{
+		{Href: "https://hub.example.com/", Rel: "hub"},
+		{Href: "https://example.com/feed.xml", Rel: "self", Type: "application/rss+xml"},
+		{Href: "https://other.example.com/hub", Rel: "hub"},
+	}
+	if len(links) != len(expected) {
+		t.Fatalf("Expected %d links, got %+v", len(expected), links)
+	}
+	for i := range expected {
+		if links[i] != expected[i] {
+			t.Errorf("Expected link %+v, got %+v", expected[i], links[i])
+		}
+	}
+}
+
+const testWebSubAtom = `<feed xmlns="http://www.w3.org/2005/Atom">
+	<id>urn:feed</id>
+	<title>Pushed</title>
+	<updated>2024-03-05T12:00:00Z</updated>
+	<link rel="hub" href="HUB"/>
+	<link rel="self" href="/feed.atom"/>
+	<entry><id>urn:1</id><title>New entry</title><updated>2024-03-05T12:00:00Z</updated></entry>
+</feed>`
+
+// TestDiscoverHub tests discovering hubs from Link headers and feed links
+func TestDiscoverHub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		if r.URL.Path == "/header.atom" {
+			w.Header().Set("Link", `<https://header-hub.example.com/>; rel="hub"`)
+		}
+		io.WriteString(w, strings.Replace(testWebSubAtom, "HUB", "https://feed-hub.example.com/", 1))
+	}))
+	defer server.Close()
+
+	hub, err := DiscoverHub(context.Background(), server.URL+"/feed.atom", server.Client())
+	if err != nil {
+		t.Fatalf("DiscoverHub failed: %v", err)
+	}
+	if len(hub.Hubs) != 1 || hub.Hubs[0] != "https://feed-hub.example.com/" || hub.Self != server.URL+"/feed.atom" {
+		t.Errorf("Unexpected hub links %+v", hub)
+	}
+
+	hub, err = DiscoverHub(context.Background(), server.URL+"/header.atom", server.Client())
+	if err != nil {
+		t.Fatalf("DiscoverHub failed: %v", err)
+	}
+	if len(hub.Hubs) != 1 || hub.Hubs[0] != "https://header-hub.example.com/" || hub.Self != server.URL+"/feed.atom" {
+		t.Errorf("Expected Link header to take precedence, got %+v", hub)
+	}
+
+	// A nil client uses http.DefaultClient
+	hub, err = DiscoverHub(context.Background(), server.URL+"/feed.atom", nil)
+	if err != nil {
+		t.Fatalf("DiscoverHub failed: %v", err)
+	}
+	if len(hub.Hubs) != 1 || hub.Hubs[0] != "https://feed-hub.example.com/" {
+		t.Errorf("Unexpected hub links %+v", hub)
+	}
+}
+
+// testHub is a local stand-in for a WebSub hub that verifies
+// subscriptions and distributes content to the subscribers.
+type testHub struct {
+	t          *testing.T
+	mu         sync.Mutex
+	callbacks  map[string]string // topic -> callback
+	secrets    map[string]string // topic -> secret
+	verified   chan string
+	leaseInSec string
+}
+
+func newTestHub(t *testing.T) *testHub {
+	return &testHub{
+		t:          t,
+		callbacks:  make(map[string]string),
+		secrets:    make(map[string]string),
+		verified:   make(chan string, 10),
+		leaseInSec: "3600",
+	}
+}
+
+func (h *testHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mode, topic, callback := r.PostForm.Get("hub.mode"), r.PostForm.Get("hub.topic"), r.PostForm.Get("hub.callback")
+	if topic == "" || callback == "" {
+		http.Error(w, "missing topic or callback", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	// Verify the intent of the subscriber asynchronously
+	go func() {
+		verifyURL, _ := url.Parse(callback)
+		query := url.Values{
+			"hub.mode":          {mode},
+			"hub.topic":         {topic},
+			"hub.challenge":     {"challenge-" + mode},
+			"hub.lease_seconds": {h.leaseInSec},
+		}
+		verifyURL.RawQuery = query.Encode()
+		resp, err := http.Get(verifyURL.String())
+		if err != nil {
+			h.verified <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "challenge-"+mode {
+			h.verified <- "rejected"
+			return
+		}
+		h.mu.Lock()
+		if mode == "subscribe" {
+			h.callbacks[topic] = callback
+			h.secrets[topic] = r.PostForm.Get("hub.secret")
+		} else {
+			delete(h.callbacks, topic)
+		}
+		h.mu.Unlock()
+		h.verified <- mode
+	}()
+}
+
+// publish distributes the content to the subscriber of the topic
+// and returns the status code of the subscriber.
+func (h *testHub) publish(topic, content, secret string) int {
+	h.mu.Lock()
+	callback := h.callbacks[topic]
+	if secret == "" {
+		secret = h.secrets[topic]
+	}
+	h.mu.Unlock()
+
+	req, _ := http.NewRequest(http.MethodPost, callback, strings.NewReader(content))
+	req.Header.Set("Content-Type", "application/atom+xml")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(content))
+	req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatalf("Failed to publish: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (h *testHub) waitVerified(t *testing.T) string {
+	select {
+	case result := <-h.verified:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for verification")
+		return ""
+	}
+}
+
+// TestSubscriber tests subscribing, receiving content and unsubscribing
+// with a local stand-in hub
+func TestSubscriber(t *testing.T) {
+	hub := newTestHub(t)
+	hubServer := httptest.NewTLSServer(hub)
+	defer hubServer.Close()
+
+	feeds := make(chan *ParsedFeed, 1)
+	errs := make(chan error, 1)
+	subscriber := NewSubscriber("", func(ctx context.Context, sub Subscription, feed *ParsedFeed) {
+		feeds <- feed
+	})
+	subscriber.OnError = func(sub Subscription, err error) {
+		errs <- err
+	}
+	callbackServer := httptest.NewServer(subscriber)
+	defer callbackServer.Close()
+	subscriber.CallbackURL = callbackServer.URL + "/websub/"
+	subscriber.Client = hubServer.Client()
+
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		io.WriteString(w, strings.Replace(testWebSubAtom, "HUB", hubServer.URL, 1))
+	}))
+	defer feedServer.Close()
+	topic := feedServer.URL + "/feed.atom"
+
+	sub, err := subscriber.SubscribeFeed(context.Background(), feedServer.URL+"/feed.atom")
+	if err != nil {
+		t.Fatalf("SubscribeFeed failed: %v", err)
+	}
+	if sub.Hub != hubServer.URL || sub.Topic != topic || sub.Secret == "" || sub.Verified {
+		t.Errorf("Unexpected subscription %+v", sub)
+	}
+	if result := hub.waitVerified(t); result != "subscribe" {
+		t.Fatalf("Expected subscription to be verified, got %s", result)
+	}
+	sub, _ = subscriber.Subscription(sub.ID)
+	if !sub.Verified || time.Until(sub.Expires) < 59*time.Minute {
+		t.Errorf("Expected verified subscription with lease, got %+v", sub)
+	}
+
+	// Signed content is delivered as parsed feed
+	if status := hub.publish(topic, testWebSubAtom, ""); status != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", status)
+	}
+	select {
+	case feed := <-feeds:
+		if feed.Format != FormatAtom || len(feed.Feed.Entry) != 1 || feed.Feed.Entry[0].Title != "New entry" {
+			t.Errorf("Unexpected pushed feed %+v", feed)
+		}
+	default:
+		t.Error("Expected pushed feed")
+	}
+
+	// Content with wrong signature is acknowledged but ignored
+	if status := hub.publish(topic, testWebSubAtom, "wrong secret"); status != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", status)
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	default:
+		t.Error("Expected invalid signature error")
+	}
+	if len(feeds) != 0 {
+		t.Error("Expected content with wrong signature to be ignored")
+	}
+
+	// Verifications for other topics are rejected
+	resp, err := http.Get(subscriber.CallbackURL + sub.ID + "?hub.mode=subscribe&hub.challenge=x&hub.topic=https://other.example.com/")
+	if err != nil {
+		t.Fatalf("Failed to send verification: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for other topic, got %d", resp.StatusCode)
+	}
+
+	if err := subscriber.Unsubscribe(context.Background(), sub.ID); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if result := hub.waitVerified(t); result != "unsubscribe" {
+		t.Fatalf("Expected unsubscription to be verified, got %s", result)
+	}
+	if _, ok := subscriber.Subscription(sub.ID); ok {
+		t.Error("Expected subscription to be removed")
+	}
+}
+
+// TestSubscriberDenied tests subscriptions denied by the hub
+func TestSubscriberDenied(t *testing.T) {
+	hubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hubServer.Close()
+
+	var denied error
+	subscriber := NewSubscriber("https://subscriber.example.com/", nil)
+	subscriber.OnError = func(sub Subscription, err error) {
+		denied = err
+	}
+	sub, err := subscriber.Subscribe(context.Background(), hubServer.URL, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	query := url.Values{"hub.mode": {"denied"}, "hub.topic": {sub.Topic}, "hub.reason": {"not allowed"}}
+	rec := httptest.NewRecorder()
+	subscriber.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+sub.ID+"?"+query.Encode(), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if !errors.Is(denied, ErrSubscriptionDenied) || !strings.Contains(denied.Error(), "not allowed") {
+		t.Errorf("Expected ErrSubscriptionDenied, got %v", denied)
+	}
+	if len(subscriber.Subscriptions()) != 0 {
+		t.Error("Expected denied subscription to be removed")
+	}
+
+	// Content for unknown subscriptions is rejected with 410 Gone
+	rec = httptest.NewRecorder()
+	subscriber.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/"+sub.ID, strings.NewReader(testWebSubAtom)))
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected status 410, got %d", rec.Code)
+	}
+
+	// Hub errors are returned by Subscribe
+	failingHub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer failingHub.Close()
+	if _, err := subscriber.Subscribe(context.Background(), failingHub.URL, "https://example.com/feed"); err == nil {
+		t.Error("Expected error for failing hub")
+	}
+	if len(subscriber.Subscriptions()) != 0 {
+		t.Error("Expected failed subscription to be removed")
+	}
+}
+
+// TestSubscriberRestore tests that restored subscriptions receive content after a restart
+func TestSubscriberRestore(t *testing.T) {
+	hub := newTestHub(t)
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+
+	// The callback URL stays the same across the restart
+	var current atomic.Pointer[Subscriber]
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().ServeHTTP(w, r)
+	}))
+	defer callbackServer.Close()
+
+	before := NewSubscriber(callbackServer.URL+"/", nil)
+	current.Store(before)
+	sub, err := before.Subscribe(context.Background(), hubServer.URL, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if result := hub.waitVerified(t); result != "subscribe" {
+		t.Fatalf("Expected subscription to be verified, got %s", result)
+	}
+	saved, _ := before.Subscription(sub.ID)
+
+	feeds := make(chan *ParsedFeed, 1)
+	after := NewSubscriber(callbackServer.URL+"/", func(ctx context.Context, sub Subscription, feed *ParsedFeed) {
+		feeds <- feed
+	})
+	current.Store(after)
+	if status := hub.publish(saved.Topic, testWebSubAtom, ""); status != http.StatusGone {
+		t.Errorf("Expected status 410 before restore, got %d", status)
+	}
+
+	if err := after.Restore(saved); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored, ok := after.Subscription(sub.ID); !ok || restored != saved {
+		t.Errorf("Expected restored subscription %+v, got %+v", saved, restored)
+	}
+	if status := hub.publish(saved.Topic, testWebSubAtom, ""); status != http.StatusOK {
+		t.Errorf("Expected status 200 after restore, got %d", status)
+	}
+	select {
+	case feed := <-feeds:
+		if feed.Format != FormatAtom || len(feed.Feed.Entry) != 1 {
+			t.Errorf("Unexpected pushed feed %+v", feed)
+		}
+	default:
+		t.Error("Expected pushed feed after restore")
+	}
+
+	if err := after.Restore(Subscription{Hub: hubServer.URL, Topic: saved.Topic}); err == nil {
+		t.Error("Expected error for subscription without ID")
+	}
+}
+
+// TestSubscriberUnsubscribeFailed tests that a failed unsubscription keeps the subscription active
+func TestSubscriberUnsubscribeFailed(t *testing.T) {
+	hubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer hubServer.Close()
+
+	subscriber := NewSubscriber("https://subscriber.example.com/", nil)
+	sub := Subscription{ID: "sub1", Hub: hubServer.URL, Topic: "https://example.com/feed", Verified: true}
+	if err := subscriber.Restore(sub); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := subscriber.Unsubscribe(context.Background(), sub.ID); err == nil {
+		t.Fatal("Expected error for failing hub")
+	}
+
+	// The hub can still renew the subscription and distribute content
+	query := url.Values{"hub.mode": {"subscribe"}, "hub.topic": {sub.Topic}, "hub.challenge": {"renewed"}}
+	rec := httptest.NewRecorder()
+	subscriber.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+sub.ID+"?"+query.Encode(), nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "renewed" {
+		t.Errorf("Expected renewal to be verified, got %d %q", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	subscriber.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/"+sub.ID, strings.NewReader(testWebSubAtom)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected content to be accepted, got %d", rec.Code)
+	}
+}
+
+// TestSubscriberInsecureHub tests that secrets are not sent to hubs without https
+func TestSubscriberInsecureHub(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		requests []url.Values
+	)
+	hubServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mtx.Lock()
+		requests = append(requests, r.PostForm)
+		mtx.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hubServer.Close()
+
+	subscriber := NewSubscriber("https://subscriber.example.com/", nil)
+	sub, err := subscriber.Subscribe(context.Background(), hubServer.URL, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if sub.Secret != "" {
+		t.Errorf("Expected no secret for http hub, got %q", sub.Secret)
+	}
+	if len(requests) != 1 || requests[0].Has("hub.secret") {
+		t.Errorf("Expected subscription without secret, got %v", requests)
+	}
+
+	// Restored subscriptions with a secret are not renewed in clear text
+	restored := Subscription{ID: "sub1", Hub: hubServer.URL, Topic: "https://example.com/other", Secret: "s3cret"}
+	if err := subscriber.Restore(restored); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := subscriber.Renew(context.Background(), restored.ID); err == nil || !strings.Contains(err.Error(), "insecure hub") {
+		t.Errorf("Expected error for secret to http hub, got %v", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected no request with secret, got %v", requests[1:])
+	}
+}
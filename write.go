@@ -0,0 +1,65 @@
+package rss
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// WriteRegular writes the channel as indented RSS 2.0 document to w.
+// Empty optional elements are omitted.
+func WriteRegular(w io.Writer, channel *Channel) error {
+	rss := struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel *Channel `xml:"channel"`
+	}{
+		Version: "2.0",
+		Channel: channel,
+	}
+	return writeXML(w, rss, xml.StartElement{})
+}
+
+// WriteAtom writes the feed as indented Atom 1.0 document to w.
+// Empty optional elements are omitted.
+func WriteAtom(w io.Writer, feed *Feed) error {
+	return writeXML(w, feed, xml.StartElement{Name: xml.Name{Space: atomNamespace, Local: "feed"}})
+}
+
+// WriteJSONFeed writes the feed as indented JSON Feed document to w.
+func WriteJSONFeed(w io.Writer, feed *JSONFeed) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(feed); err != nil {
+		return fmt.Errorf("failed to write JSON Feed: %w", err)
+	}
+	return nil
+}
+
+// writeXML writes v with an XML declaration to w,
+// using start as root element if its name is not empty.
+func writeXML(w io.Writer, v any, start xml.StartElement) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	var err error
+	if start.Name.Local != "" {
+		err = encoder.EncodeElement(v, start)
+	} else {
+		err = encoder.Encode(v)
+	}
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err == nil {
+		_, err = io.WriteString(w, "\n")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWriteRegular tests that written RSS documents parse to the same channel
+func TestWriteRegular(t *testing.T) {
+	file, err := os.Open(filepath.Join(testDataDir, "podcast.rss"))
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+	channel, err := ParseRegular(context.Background(), file)
+	if err != nil {
+		t.Fatalf("ParseRegular failed: %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := WriteRegular(&b, channel); err != nil {
+		t.Fatalf("WriteRegular failed: %v", err)
+	}
+	if !strings.HasPrefix(b.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<rss version="2.0">`) {
+		t.Errorf("Unexpected document start: %.80s", b.String())
+	}
+	if strings.Contains(b.String(), "<comments>") {
+		t.Error("Expected empty elements to be omitted")
+	}
+
+	parsed, err := ParseRegular(context.Background(), &b)
+	if err != nil {
+		t.Fatalf("ParseRegular of written document failed: %v", err)
+	}
+	if parsed.Title != channel.Title || len(parsed.Item) != len(channel.Item) {
+		t.Fatalf("Expected %q with %d items, got %q with %d", channel.Title, len(channel.Item), parsed.Title, len(parsed.Item))
+	}
+	if parsed.Item[0].Description != channel.Item[0].Description || parsed.Item[0].Enclosure[0] != channel.Item[0].Enclosure[0] {
+		t.Errorf("Unexpected first item: %+v", parsed.Item[0])
+	}
+}
+
+// TestWriteAtom tests that written Atom documents parse to the same feed
+func TestWriteAtom(t *testing.T) {
+	feed := &Feed{
+		ID:      "urn:feed",
+		Title:   "Feed",
+		Updated: "2024-01-01T12:00:00Z",
+		Link:    []Link{{Href: "https://example.com/", Rel: "alternate"}},
+		Entry: []Entry{{
+			ID:      "urn:entry",
+			Title:   "Entry",
+			Updated: "2024-01-01T12:00:00Z",
+			Summary: Content{Type: "html", Body: "<p>Fish &amp; Chips</p>"},
+			Content: Content{Type: "xhtml", Body: "<p>Hello <b>World</b></p>"},
+		}},
+	}
+
+	var b bytes.Buffer
+	if err := WriteAtom(&b, feed); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+	if !strings.Contains(b.String(), `<feed xmlns="http://www.w3.org/2005/Atom">`) {
+		t.Errorf("Expected Atom namespace, got:\n%s", b.String())
+	}
+
+	parsed, err := ParseAtom(context.Background(), &b)
+	if err != nil {
+		t.Fatalf("ParseAtom of written document failed: %v", err)
+	}
+	entry := parsed.Entry[0]
+	if entry.Summary != feed.Entry[0].Summary {
+		t.Errorf("Expected summary %+v, got %+v", feed.Entry[0].Summary, entry.Summary)
+	}
+	if entry.Content.Type != "xhtml" || entry.Content.Body != feed.Entry[0].Content.Body {
+		t.Errorf("Expected XHTML content %q, got %+v", feed.Entry[0].Content.Body, entry.Content)
+	}
+}
+
+// TestWriteOmitsEmptyElements tests that empty optional elements are not written
+func TestWriteOmitsEmptyElements(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteRegular(&b, &Channel{Title: "Channel", Item: []Item{{Title: "Item"}}}); err != nil {
+		t.Fatalf("WriteRegular failed: %v", err)
+	}
+	for _, element := range []string{"<link>", "<description>", "<language>", "<lastBuildDate>", "<guid>", "<pubDate>", "<enclosure", "xml:base", "base="} {
+		if strings.Contains(b.String(), element) {
+			t.Errorf("Unexpected %s in:\n%s", element, b.String())
+		}
+	}
+
+	b.Reset()
+	feed := &Feed{
+		ID:      "urn:feed",
+		Title:   "Feed",
+		Updated: "2024-01-01T12:00:00Z",
+		Entry:   []Entry{{ID: "urn:entry", Title: "Entry", Updated: "2024-01-01T12:00:00Z", Author: []Person{{Name: "Jane"}}}},
+	}
+	if err := WriteAtom(&b, feed); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+	for _, element := range []string{"<link", "<published>", "<summary", "<content", "<uri>", "<email>", "base="} {
+		if strings.Contains(b.String(), element) {
+			t.Errorf("Unexpected %s in:\n%s", element, b.String())
+		}
+	}
+	if !strings.Contains(b.String(), "<name>Jane</name>") {
+		t.Errorf("Expected author name in:\n%s", b.String())
+	}
+	if _, err := ParseAtom(context.Background(), &b); err != nil {
+		t.Errorf("ParseAtom of written document failed: %v", err)
+	}
+}
+
+// TestWriteJSONFeed tests that written JSON Feeds parse to the same feed
+func TestWriteJSONFeed(t *testing.T) {
+	feed := &JSONFeed{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title:   "Fish & Chips",
+		Items:   []JSONFeedItem{{ID: "1", URL: "https://example.com/1", ContentHTML: "<p>Hello</p>"}},
+	}
+
+	var b bytes.Buffer
+	if err := WriteJSONFeed(&b, feed); err != nil {
+		t.Fatalf("WriteJSONFeed failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "\n  \"title\": \"Fish & Chips\"") || !strings.Contains(b.String(), `"content_html": "<p>Hello</p>"`) {
+		t.Errorf("Expected indented JSON without HTML escaping, got:\n%s", b.String())
+	}
+
+	parsed, err := ParseJSONFeed(context.Background(), &b)
+	if err != nil {
+		t.Fatalf("ParseJSONFeed of written document failed: %v", err)
+	}
+	if parsed.Title != feed.Title || len(parsed.Items) != 1 || parsed.Items[0].ContentHTML != "<p>Hello</p>" {
+		t.Errorf("Unexpected feed after round trip: %+v", parsed)
+	}
+}